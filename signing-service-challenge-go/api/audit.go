@@ -0,0 +1,48 @@
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+type AuditResponse struct {
+	Valid          bool   `json:"valid"`
+	SignatureCount int    `json:"signature_count"`
+	BrokenAt       *int   `json:"broken_at,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// Audit the signature chain of a signature device
+func (s *Server) auditSignatureDevice(response http.ResponseWriter, request *http.Request, deviceId string) {
+	if request.Method != http.MethodGet {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	// the device is read first, repositories store signatures before the counter
+	// that covers them, so every signature up to this counter is stored already
+	signatureDevice, err := s.deviceRepository.FindById(deviceId)
+	if err != nil {
+		log.Printf("Error while finding signature device: %v", err)
+		WriteError(response, request, err)
+		return
+	}
+
+	signatures, err := s.transactionRepository.FindByDeviceId(deviceId)
+	if err != nil {
+		log.Printf("Error while finding transactions: %v", err)
+		WriteInternalError(response)
+		return
+	}
+
+	report := signatureDevice.Audit(signatures)
+	auditResponse := AuditResponse{
+		Valid:          report.Valid,
+		SignatureCount: report.SignatureCount,
+		Reason:         report.Reason,
+	}
+	if !report.Valid {
+		auditResponse.BrokenAt = &report.BrokenAt
+	}
+	WriteAPIResponse(response, http.StatusOK, auditResponse)
+}
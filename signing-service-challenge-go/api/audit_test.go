@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestAuditSignatureDevice(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+	for _, data := range []string{"first", "second"} {
+		signature, _ := signatureDevice.Sign(data)
+		s.transactionRepository.Save(signature)
+	}
+
+	w := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/api/v0/signature-device/123/audit", nil)
+	s.SignatureDeviceResource(w, request)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status code 200, got %d", w.Code)
+	}
+	var responseBody struct {
+		Data AuditResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&responseBody); err != nil {
+		t.Fatalf("Error while unmarshalling response body: %v", err)
+	}
+	if !responseBody.Data.Valid {
+		t.Errorf("Expected chain to be valid, got reason: %s", responseBody.Data.Reason)
+	}
+	if responseBody.Data.SignatureCount != 2 {
+		t.Errorf("Expected 2 signatures, got %d", responseBody.Data.SignatureCount)
+	}
+}
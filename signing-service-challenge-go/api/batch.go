@@ -0,0 +1,96 @@
+package api
+
+import (
+	gocrypto "crypto"
+	"fmt"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// MaxBatchSize limits the number of payloads signed by a single batch request
+const MaxBatchSize = 1000
+
+type SignBatchRequest struct {
+	Id string `json:"id"`
+	// Data are signed in the given order
+	Data []string `json:"data"`
+	// DataEncoding, DigestEncoding, SignatureFormat, SignatureEncoding and JWS
+	// apply to every payload and work as for a single signature
+	DataEncoding      string `json:"data_encoding,omitempty"`
+	DigestEncoding    string `json:"digest_encoding,omitempty"`
+	SignatureFormat   string `json:"signature_format,omitempty"`
+	SignatureEncoding string `json:"signature_encoding,omitempty"`
+	JWS               bool   `json:"jws,omitempty"`
+}
+
+type BatchSignature struct {
+	Counter int `json:"counter"`
+	SignDataResponse
+}
+
+type SignBatchResponse struct {
+	Signatures []BatchSignature `json:"signatures"`
+}
+
+// Sign an ordered batch of data with a signature device. The device lock is
+// held for the whole batch, so the signatures get contiguous counters.
+func (s *Server) SignBatch(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	var signBatchRequest SignBatchRequest
+	err := decodeRequest(response, request, MaxBatchRequestBodySize, &signBatchRequest)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+
+	batch, err := validateSignBatchRequest(signBatchRequest)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+	signBatchRequest.Id = canonicalDeviceId(signBatchRequest.Id)
+
+	var signatures []*domain.Signature
+	var publicKey gocrypto.PublicKey
+	err = s.deviceRepository.Update(signBatchRequest.Id, func(signatureDevice *domain.SignatureDevice) ([]*domain.Signature, error) {
+		var err error
+		signatures, err = signatureDevice.SignBatch(batch, domain.SignOptions{
+			Digest: signBatchRequest.DigestEncoding != "",
+			JWS:    signBatchRequest.JWS,
+			Clock:  s.clock,
+		})
+		publicKey = signatureDevice.PublicKey()
+		return signatures, err
+	})
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+
+	signBatchResponse := SignBatchResponse{
+		Signatures: make([]BatchSignature, 0, len(signatures)),
+	}
+	for _, signature := range signatures {
+		signDataResponse, err := newSignDataResponse(
+			publicKey,
+			signature,
+			signBatchRequest.SignatureFormat,
+			signBatchRequest.SignatureEncoding,
+			signBatchRequest.DataEncoding,
+		)
+		if err != nil {
+			WriteError(response, request, fmt.Errorf("encoding signature: %w", err))
+			return
+		}
+		signBatchResponse.Signatures = append(signBatchResponse.Signatures, BatchSignature{
+			Counter:          signature.Counter,
+			SignDataResponse: signDataResponse,
+		})
+	}
+	WriteAPIResponse(response, http.StatusOK, signBatchResponse)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestSignBatch(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice, _ := domain.NewSignatureDeviceWithFraming(testDeviceId, "test_device", signer, domain.FRAMING_V1)
+	s.deviceRepository.Save(signatureDevice)
+
+	signBatch := func(signBatchRequest SignBatchRequest) (*httptest.ResponseRecorder, SignBatchResponse) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(signBatchRequest)
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign-batch", bytes.NewBuffer(requestBody))
+		s.SignBatch(w, request)
+
+		var responseBody struct {
+			Data SignBatchResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		return w, responseBody.Data
+	}
+
+	t.Run("ordered batch", func(t *testing.T) {
+		w, signBatchResponse := signBatch(SignBatchRequest{Id: testDeviceId, Data: []string{"first", "second", "third"}})
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		if len(signBatchResponse.Signatures) != 3 {
+			t.Fatalf("Expected 3 signatures, got %d", len(signBatchResponse.Signatures))
+		}
+		for i, signature := range signBatchResponse.Signatures {
+			if signature.Counter != i {
+				t.Errorf("Expected counter %d, got %d", i, signature.Counter)
+			}
+			securedData, err := domain.ParseSecuredData([]byte(signature.SignedData))
+			if err != nil || securedData.Counter != i {
+				t.Errorf("Expected signed data with counter %d, got %v", i, err)
+			}
+		}
+	})
+
+	t.Run("invalid payloads are all reported", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(SignBatchRequest{Id: testDeviceId, Data: []string{"AA==", "!", "?"}, DataEncoding: "base64"})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign-batch", bytes.NewBuffer(requestBody))
+		s.SignBatch(w, request)
+
+		var responseBody ProblemDetails
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if w.Code != 400 || len(responseBody.InvalidParams) != 2 {
+			t.Errorf("Expected status code 400 with 2 invalid params, got %d with %v", w.Code, responseBody.InvalidParams)
+		}
+		if len(responseBody.InvalidParams) == 2 && responseBody.InvalidParams[1].Name != "data[2]" {
+			t.Errorf("Expected invalid param data[2], got %s", responseBody.InvalidParams[1].Name)
+		}
+		stored, _ := s.deviceRepository.FindById(testDeviceId)
+		if stored.SignatureCounter() != 3 {
+			t.Errorf("Expected no signature to be created, got counter %d", stored.SignatureCounter())
+		}
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		w, _ := signBatch(SignBatchRequest{Id: testDeviceId})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		w, _ := signBatch(SignBatchRequest{Id: unknownDeviceId, Data: []string{"data"}})
+		if w.Code != 404 {
+			t.Errorf("Expected status code 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("concurrent batches stay contiguous", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				signBatch(SignBatchRequest{Id: testDeviceId, Data: []string{"a", "b", "c", "d", "e"}})
+			}()
+		}
+		wg.Wait()
+
+		stored, _ := s.deviceRepository.FindById(testDeviceId)
+		signatures, _ := s.transactionRepository.FindByDeviceId(testDeviceId)
+		report := stored.Audit(signatures)
+		if !report.Valid || report.SignatureCount != 23 {
+			t.Errorf("Expected an unbroken chain of 23 signatures, got %d: %s", report.SignatureCount, report.Reason)
+		}
+	})
+}
@@ -1,9 +1,10 @@
 package api
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
@@ -18,12 +19,41 @@ func (s *Server) SignatureDevice(response http.ResponseWriter, request *http.Req
 	case http.MethodPost:
 		s.createSignatureDevice(response, request)
 	default:
-		WriteErrorResponse(response, http.StatusMethodNotAllowed, []string{
-			http.StatusText(http.StatusMethodNotAllowed),
-		})
+		WriteError(response, request, ErrMethodNotAllowed)
+	}
+}
+
+// SignatureDeviceResource dispatches requests for a single signature device
+// (/api/v0/signature-device/{id}/...) to the matching handler.
+func (s *Server) SignatureDeviceResource(response http.ResponseWriter, request *http.Request) {
+	id, resource := splitSignatureDevicePath(request.URL.Path)
+	if id == "" {
+		WriteError(response, request, ErrNotFound)
+		return
+	}
+
+	switch resource {
+	case "":
+		s.getSignatureDevice(response, request, id)
+	case "public-key":
+		s.getPublicKey(response, request, id)
+	case "verify":
+		s.verifySignature(response, request, id)
+	case "audit":
+		s.auditSignatureDevice(response, request, id)
+	case "transactions":
+		s.listTransactions(response, request, id)
+	default:
+		WriteError(response, request, ErrNotFound)
 	}
 }
 
+func splitSignatureDevicePath(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/api/v0/signature-device/")
+	id, resource, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	return id, resource
+}
+
 type SignatureDevice struct {
 	Id    string `json:"id"`
 	Label string `json:"label"`
@@ -48,113 +78,171 @@ func (s *Server) listSignatureDevices(response http.ResponseWriter, _ *http.Requ
 	WriteAPIResponse(response, http.StatusOK, devices)
 }
 
-type CreateSignatureDeviceRequest struct {
-	Id        string `json:"id"`
-	Label     string `json:"label"`
-	Algorithm string `json:"algorithm"`
-}
-
-type CreateSignatureDeviceResponse struct {
-	Id        string `json:"id"`
-	Label     string `json:"label"`
-	Algorithm string `json:"algorithm"`
+type SignatureDeviceDetails struct {
+	Id               string    `json:"id"`
+	Label            string    `json:"label"`
+	Algorithm        string    `json:"algorithm"`
+	SignatureCounter int       `json:"signature_counter"`
+	KeySize          int       `json:"key_size,omitempty"`
+	Curve            string    `json:"curve,omitempty"`
+	Hash             string    `json:"hash,omitempty"`
+	Padding          string    `json:"padding,omitempty"`
+	Deterministic    bool      `json:"deterministic"`
+	Framing          string    `json:"framing"`
+	LastSignature    string    `json:"last_signature"`
+	CreatedAt        time.Time `json:"created_at"`
+	PublicKey        string    `json:"public_key"`
 }
 
-func (s *Server) createSignatureDevice(response http.ResponseWriter, request *http.Request) {
-	var createSignatureDeviceRequest CreateSignatureDeviceRequest
-	err := json.NewDecoder(request.Body).Decode((&createSignatureDeviceRequest))
-	if err != nil {
-		log.Printf("Error while decoding request body: %v", err)
-		WriteErrorResponse(response, http.StatusBadRequest, []string{
-			http.StatusText(http.StatusBadRequest),
-		})
+// Retrieve a single signature device including its current state
+func (s *Server) getSignatureDevice(response http.ResponseWriter, request *http.Request, id string) {
+	if request.Method != http.MethodGet {
+		WriteError(response, request, ErrMethodNotAllowed)
 		return
 	}
 
-	signer, err := crypto.CreateSigner(createSignatureDeviceRequest.Algorithm)
+	signatureDevice, err := s.deviceRepository.FindById(id)
 	if err != nil {
-		log.Printf("Error while creating signer: %v", err)
-		WriteInternalError(response)
+		log.Printf("Error while finding signature device: %v", err)
+		WriteError(response, request, err)
 		return
 	}
 
-	uuid, err := uuid.NewRandom()
+	publicKey, err := crypto.MarshalPublicKey(signatureDevice.PublicKey())
 	if err != nil {
-		log.Printf("Error while generating UUID: %v", err)
+		log.Printf("Error while marshalling public key: %v", err)
 		WriteInternalError(response)
 		return
 	}
 
-	signatureDevice := domain.NewSignatureDevice(
-		uuid.String(),
-		createSignatureDeviceRequest.Label,
-		signer,
-	)
-	err = s.deviceRepository.Save(signatureDevice)
-	if err != nil {
-		log.Printf("Error while saving signature device: %v", err)
-		WriteErrorResponse(response, http.StatusBadRequest, []string{
-			err.Error(),
-		})
-		return
+	signatureDeviceDetails := SignatureDeviceDetails{
+		Id:               signatureDevice.Id,
+		Label:            signatureDevice.Label,
+		Algorithm:        signatureDevice.Algorithm(),
+		SignatureCounter: signatureDevice.SignatureCounter(),
+		KeySize:          signatureDevice.KeyParameters().Size,
+		Curve:            signatureDevice.KeyParameters().Curve,
+		Hash:             signatureDevice.Scheme().Hash,
+		Padding:          signatureDevice.Scheme().Padding,
+		Deterministic:    signatureDevice.Scheme().Deterministic,
+		Framing:          signatureDevice.Framing(),
+		LastSignature:    signatureDevice.LastSignature(),
+		CreatedAt:        signatureDevice.CreatedAt(),
+		PublicKey:        string(publicKey),
 	}
-
-	createSignatureDeviceResponse := CreateSignatureDeviceResponse{
-		Id:        signatureDevice.Id,
-		Label:     signatureDevice.Label,
-		Algorithm: createSignatureDeviceRequest.Algorithm,
-	}
-	WriteAPIResponse(response, http.StatusCreated, createSignatureDeviceResponse)
+	WriteAPIResponse(response, http.StatusOK, signatureDeviceDetails)
 }
 
-type SignDataRequest struct {
-	Id   string `json:"id"`
-	Data string `json:"data"`
+type CreateSignatureDeviceRequest struct {
+	Id            string `json:"id"`
+	Label         string `json:"label"`
+	Algorithm     string `json:"algorithm"`
+	KeySize       int    `json:"key_size,omitempty"`
+	Curve         string `json:"curve,omitempty"`
+	Hash          string `json:"hash,omitempty"`
+	Padding       string `json:"padding,omitempty"`
+	Deterministic bool   `json:"deterministic,omitempty"`
+	// Framing is "v1" (default) for length-prefixed secured data covering the
+	// signing time and data mode, or "legacy" for the original format
+	Framing string `json:"framing,omitempty"`
 }
 
-type SignDataResponse struct {
-	Signature  string `json:"signature"`
-	SignedData string `json:"signed_data"`
+type CreateSignatureDeviceResponse struct {
+	Id            string `json:"id"`
+	Label         string `json:"label"`
+	Algorithm     string `json:"algorithm"`
+	KeySize       int    `json:"key_size,omitempty"`
+	Curve         string `json:"curve,omitempty"`
+	Hash          string `json:"hash,omitempty"`
+	Padding       string `json:"padding,omitempty"`
+	Deterministic bool   `json:"deterministic"`
+	Framing       string `json:"framing"`
 }
 
-// Sign data with a signature device
-func (s *Server) SignData(response http.ResponseWriter, request *http.Request) {
-	if request.Method != http.MethodPost {
-		WriteErrorResponse(response, http.StatusMethodNotAllowed, []string{
-			http.StatusText(http.StatusMethodNotAllowed),
-		})
+func (s *Server) createSignatureDevice(response http.ResponseWriter, request *http.Request) {
+	var createSignatureDeviceRequest CreateSignatureDeviceRequest
+	err := decodeRequest(response, request, MaxRequestBodySize, &createSignatureDeviceRequest)
+	if err != nil {
+		WriteError(response, request, err)
 		return
 	}
 
-	var signDataRequest SignDataRequest
-	err := json.NewDecoder(request.Body).Decode((&signDataRequest))
+	err = validateCreateSignatureDeviceRequest(createSignatureDeviceRequest)
 	if err != nil {
-		log.Printf("Error while decoding request body: %v", err)
-		WriteErrorResponse(response, http.StatusBadRequest, []string{
-			http.StatusText(http.StatusBadRequest),
-		})
+		WriteError(response, request, err)
 		return
 	}
 
-	signatureDevice, err := s.deviceRepository.FindById(signDataRequest.Id)
+	id, err := signatureDeviceId(createSignatureDeviceRequest.Id)
 	if err != nil {
-		log.Printf("Error while finding signature device: %v", err)
-		WriteAPIResponse(response, http.StatusNotFound, []string{
-			err.Error(),
-		})
+		WriteError(response, request, &FieldError{Field: "id", Err: err})
 		return
 	}
 
-	signature, err := signatureDevice.Sign(signDataRequest.Data)
+	signer, err := crypto.CreateSignerWithParameters(
+		createSignatureDeviceRequest.Algorithm,
+		crypto.KeyParameters{
+			Size:  createSignatureDeviceRequest.KeySize,
+			Curve: createSignatureDeviceRequest.Curve,
+		},
+		crypto.SignatureScheme{
+			Hash:          createSignatureDeviceRequest.Hash,
+			Padding:       createSignatureDeviceRequest.Padding,
+			Deterministic: createSignatureDeviceRequest.Deterministic,
+		},
+	)
 	if err != nil {
-		log.Printf("Error while signing data: %v", err)
-		WriteInternalError(response)
+		WriteError(response, request, err)
+		return
+	}
+
+	framing := createSignatureDeviceRequest.Framing
+	if framing == "" {
+		framing = domain.FRAMING_V1
+	}
+	signatureDevice, err := domain.NewSignatureDeviceWithFraming(
+		id,
+		createSignatureDeviceRequest.Label,
+		signer,
+		framing,
+	)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+	err = s.deviceRepository.Save(signatureDevice)
+	if err != nil {
+		WriteError(response, request, err)
 		return
 	}
 
-	signDataResponse := SignDataResponse{
-		Signature:  signature.Signature,
-		SignedData: signature.Signed_Data,
+	createSignatureDeviceResponse := CreateSignatureDeviceResponse{
+		Id:            signatureDevice.Id,
+		Label:         signatureDevice.Label,
+		Algorithm:     signatureDevice.Algorithm(),
+		KeySize:       signatureDevice.KeyParameters().Size,
+		Curve:         signatureDevice.KeyParameters().Curve,
+		Hash:          signatureDevice.Scheme().Hash,
+		Padding:       signatureDevice.Scheme().Padding,
+		Deterministic: signatureDevice.Scheme().Deterministic,
+		Framing:       signatureDevice.Framing(),
+	}
+	WriteAPIResponse(response, http.StatusCreated, createSignatureDeviceResponse)
+}
+
+// signatureDeviceId validates a client supplied UUID in its canonical form,
+// a random UUID is generated if the client did not supply one
+func signatureDeviceId(id string) (string, error) {
+	if id == "" {
+		generated, err := uuid.NewRandom()
+		if err != nil {
+			return "", err
+		}
+		return generated.String(), nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return "", ErrInvalidDeviceId
 	}
-	WriteAPIResponse(response, http.StatusOK, signDataResponse)
+	return parsed.String(), nil
 }
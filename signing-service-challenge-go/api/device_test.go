@@ -51,26 +51,198 @@ func TestCreateSignatureDevice(t *testing.T) {
 			t.Errorf("Expected signature device with id %s, got %s", deviceId, signatureDevice.Id)
 		}
 	})
+
+	t.Run("client supplied id", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Id: "6F9619FF-8B86-D011-B42D-00C04FC964FF", Algorithm: "ECC"})
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		if _, err := s.deviceRepository.FindById("6f9619ff-8b86-d011-b42d-00c04fc964ff"); err != nil {
+			t.Errorf("Expected device with the canonical client supplied id, got %v", err)
+		}
+	})
+
+	t.Run("existing id", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Id: "6f9619ff-8b86-d011-b42d-00c04fc964ff", Algorithm: "ECC"})
+		if w.Code != 409 {
+			t.Errorf("Expected status code 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Id: "device-1", Algorithm: "ECC"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
 }
 
 func TestSignature(t *testing.T) {
 	s := NewServer(":8080")
 	signer, _ := crypto.CreateSigner("RSA")
-	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	signatureDevice := domain.NewSignatureDevice(testDeviceId, "test_device", signer)
 	s.deviceRepository.Save(signatureDevice)
 	w := httptest.NewRecorder()
 	requestBody, err := json.Marshal(SignDataRequest{
-		Id:   "123",
+		Id:   testDeviceId,
 		Data: "test_data",
 	})
 	if err != nil {
 		t.Errorf("Error while marshalling request body: %v", err)
 	}
 
-	request := httptest.NewRequest("POST", "/api/v0/signature-device/123/signature", bytes.NewBuffer(requestBody))
+	request := httptest.NewRequest("POST", "/api/v0/signature-device/"+testDeviceId+"/signature", bytes.NewBuffer(requestBody))
 	s.SignData(w, request)
 
 	if w.Code != 200 {
 		t.Errorf("Expected status code 200, got %d", w.Code)
 	}
 }
+
+func TestCreateSignatureDevice_KeyParameters(t *testing.T) {
+	s := NewServer(":8080")
+
+	t.Run("supported curve", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC", Curve: "P-256"})
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data CreateSignatureDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if responseBody.Data.Curve != "P-256" {
+			t.Errorf("Expected curve P-256, got %s", responseBody.Data.Curve)
+		}
+	})
+
+	t.Run("insecure key size", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "RSA", KeySize: 512})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("signature scheme", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "RSA", Hash: "SHA-512", Padding: "PKCS1v15"})
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data CreateSignatureDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if responseBody.Data.Hash != "SHA-512" || responseBody.Data.Padding != "PKCS1v15" {
+			t.Errorf("Expected SHA-512 with PKCS1v15, got %s with %s", responseBody.Data.Hash, responseBody.Data.Padding)
+		}
+	})
+
+	t.Run("unsupported signature scheme", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC", Padding: "PSS"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("framing", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC", Framing: "v1"})
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data CreateSignatureDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if responseBody.Data.Framing != "v1" {
+			t.Errorf("Expected framing v1, got %s", responseBody.Data.Framing)
+		}
+	})
+
+	t.Run("default framing", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC"})
+		var responseBody struct {
+			Data CreateSignatureDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if responseBody.Data.Framing != "v1" {
+			t.Errorf("Expected default framing v1, got %s", responseBody.Data.Framing)
+		}
+	})
+
+	t.Run("unsupported framing", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC", Framing: "json"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("legacy framing", func(t *testing.T) {
+		w := createDevice(s, CreateSignatureDeviceRequest{Algorithm: "ECC", Framing: "legacy"})
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data CreateSignatureDeviceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		if responseBody.Data.Framing != "legacy" {
+			t.Errorf("Expected framing legacy, got %s", responseBody.Data.Framing)
+		}
+	})
+}
+
+func TestGetSignatureDevice(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+	signature, _ := signatureDevice.Sign("test_data")
+
+	t.Run("existing device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/api/v0/signature-device/123", nil)
+		s.SignatureDeviceResource(w, request)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data SignatureDeviceDetails `json:"data"`
+		}
+		err := json.NewDecoder(w.Body).Decode(&responseBody)
+		if err != nil {
+			t.Fatalf("Error while unmarshalling response body: %v", err)
+		}
+		if responseBody.Data.Algorithm != "ECC" {
+			t.Errorf("Expected algorithm ECC, got %s", responseBody.Data.Algorithm)
+		}
+		if responseBody.Data.SignatureCounter != 1 {
+			t.Errorf("Expected signature counter 1, got %d", responseBody.Data.SignatureCounter)
+		}
+		if responseBody.Data.LastSignature != signature.Signature {
+			t.Errorf("Expected last signature %s, got %s", signature.Signature, responseBody.Data.LastSignature)
+		}
+		if responseBody.Data.PublicKey == "" {
+			t.Error("Expected public key to be set")
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/api/v0/signature-device/456", nil)
+		s.SignatureDeviceResource(w, request)
+
+		if w.Code != 404 {
+			t.Errorf("Expected status code 404, got %d", w.Code)
+		}
+	})
+}
+
+// createDevice sends a create request for a signature device to the server
+func createDevice(s *Server, createSignatureDeviceRequest CreateSignatureDeviceRequest) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	requestBody, _ := json.Marshal(createSignatureDeviceRequest)
+	request := httptest.NewRequest("POST", "/api/v0/signature-device", bytes.NewBuffer(requestBody))
+	s.SignatureDevice(w, request)
+	return w
+}
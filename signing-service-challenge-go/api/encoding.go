@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/base64"
+	"unicode/utf8"
+)
+
+const (
+	// DataEncodingUTF8 passes data as plain JSON string
+	DataEncodingUTF8 = "utf8"
+	// DataEncodingBase64 passes arbitrary binary data as standard base64
+	DataEncodingBase64 = "base64"
+)
+
+// decodeData returns the exact bytes of data passed in the given encoding
+func decodeData(data string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", DataEncodingUTF8:
+		return []byte(data), nil
+	case DataEncodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, ErrInvalidData
+		}
+		return decoded, nil
+	default:
+		return nil, ErrUnsupportedDataEncoding
+	}
+}
+
+// encodeData encodes data for a JSON response. Data is returned as plain string
+// if utf8 is preferred and the data is valid UTF-8, otherwise as base64. The
+// chosen encoding is returned alongside, so clients never have to guess.
+func encodeData(data []byte, preferred string) (string, string) {
+	if preferred != DataEncodingBase64 && utf8.Valid(data) {
+		return string(data), DataEncodingUTF8
+	}
+	return base64.StdEncoding.EncodeToString(data), DataEncodingBase64
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+)
+
+// Errors of the API layer itself, errors of the other packages are mapped in errorMappings
+var (
+	ErrNotFound                  = errors.New(http.StatusText(http.StatusNotFound))
+	ErrMethodNotAllowed          = errors.New(http.StatusText(http.StatusMethodNotAllowed))
+	ErrNotAcceptable             = errors.New(http.StatusText(http.StatusNotAcceptable))
+	ErrInvalidRequestBody        = errors.New("request body is not valid JSON")
+	ErrInvalidDeviceId           = errors.New("id must be a UUID")
+	ErrUnsupportedFormat         = errors.New("unsupported signature format or encoding")
+	ErrUnsupportedDataEncoding   = errors.New("unsupported data encoding")
+	ErrUnsupportedDigestEncoding = errors.New("unsupported digest encoding")
+	ErrConflictingEncodings      = errors.New("data_encoding cannot be combined with digest_encoding")
+	ErrInvalidData               = errors.New("data is not valid base64")
+	ErrInvalidSignatureEncoding  = errors.New("signature is not valid base64")
+	ErrInvalidBatchSize          = errors.New("batch must not be empty or exceed the maximum batch size")
+	ErrConflictingIdempotencyKey = errors.New("transaction_id does not match the Idempotency-Key header")
+	ErrIdempotencyKeyReused      = errors.New("idempotency key was already used for a different request")
+)
+
+// Machine readable error codes
+const (
+	ErrorCodeNotFound            = "not_found"
+	ErrorCodeMethodNotAllowed    = "method_not_allowed"
+	ErrorCodeNotAcceptable       = "not_acceptable"
+	ErrorCodeInvalidRequest      = "invalid_request"
+	ErrorCodeRequestTooLarge     = "request_too_large"
+	ErrorCodeDeviceNotFound      = "device_not_found"
+	ErrorCodeDeviceExists        = "device_exists"
+	ErrorCodeUnknownAlgorithm    = "unknown_algorithm"
+	ErrorCodeInvalidKeyParams    = "invalid_key_parameters"
+	ErrorCodeInvalidScheme       = "invalid_signature_scheme"
+	ErrorCodeUnsupportedFraming  = "unsupported_framing"
+	ErrorCodeInvalidDigest       = "invalid_digest"
+	ErrorCodeInvalidData         = "invalid_data"
+	ErrorCodeConcurrentUpdate    = "concurrent_update"
+	ErrorCodeIdempotencyConflict = "idempotency_key_reused"
+	ErrorCodeInternal            = "internal_error"
+)
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings assigns every known error its HTTP status code and error code.
+// Errors that are not listed are internal errors.
+var errorMappings = []errorMapping{
+	{ErrNotFound, http.StatusNotFound, ErrorCodeNotFound},
+	{ErrMethodNotAllowed, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed},
+	{ErrNotAcceptable, http.StatusNotAcceptable, ErrorCodeNotAcceptable},
+	{ErrInvalidRequestBody, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrRequestBodyTooLarge, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge},
+	{ErrUnknownField, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrMissingField, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrLabelTooLong, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrInvalidLabel, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrInvalidDeviceId, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrUnsupportedFormat, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrUnsupportedDataEncoding, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrUnsupportedDigestEncoding, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrConflictingEncodings, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrInvalidBatchSize, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrConflictingIdempotencyKey, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	{ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, ErrorCodeIdempotencyConflict},
+	{ErrInvalidData, http.StatusBadRequest, ErrorCodeInvalidData},
+	{ErrInvalidSignatureEncoding, http.StatusBadRequest, ErrorCodeInvalidData},
+	{crypto.ErrUnknownAlgorithm, http.StatusBadRequest, ErrorCodeUnknownAlgorithm},
+	{crypto.ErrInvalidKeyParameters, http.StatusBadRequest, ErrorCodeInvalidKeyParams},
+	{crypto.ErrInvalidSignatureScheme, http.StatusBadRequest, ErrorCodeInvalidScheme},
+	{domain.ErrUnsupportedFraming, http.StatusBadRequest, ErrorCodeUnsupportedFraming},
+	{domain.ErrInvalidDigest, http.StatusBadRequest, ErrorCodeInvalidDigest},
+	{persistence.ErrDeviceNotFound, http.StatusNotFound, ErrorCodeDeviceNotFound},
+	{persistence.ErrDeviceExists, http.StatusConflict, ErrorCodeDeviceExists},
+	{persistence.ErrConcurrentUpdate, http.StatusConflict, ErrorCodeConcurrentUpdate},
+}
+
+// statusOf returns the HTTP status code and error code of an error
+func statusOf(err error) (int, string) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return mapping.status, mapping.code
+		}
+	}
+	return http.StatusInternalServerError, ErrorCodeInternal
+}
+
+// FieldError is an error caused by a single field of a request
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// WriteError maps an error to its HTTP status code and writes it as problem details.
+// Field errors, also when joined with errors.Join, are reported as invalid params.
+// Internal errors are logged and written without details.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := statusOf(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("Internal error: %v", err)
+		WriteInternalError(w)
+		return
+	}
+
+	problem := NewProblemDetails(status, code, err.Error())
+	problem.Instance = r.URL.Path
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+		if len(errs) > 1 {
+			problem.Detail = fmt.Sprintf("request has %d invalid parameters", len(errs))
+		}
+	}
+	for _, err := range errs {
+		var fieldError *FieldError
+		if errors.As(err, &fieldError) {
+			problem.InvalidParams = append(problem.InvalidParams, InvalidParam{
+				Name:   fieldError.Field,
+				Reason: fieldError.Err.Error(),
+			})
+		}
+	}
+	WriteProblem(w, problem)
+}
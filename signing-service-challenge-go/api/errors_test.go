@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"unknown algorithm", crypto.ErrUnknownAlgorithm, 400, ErrorCodeUnknownAlgorithm},
+		{"invalid digest", domain.ErrInvalidDigest, 400, ErrorCodeInvalidDigest},
+		{"device not found", persistence.ErrDeviceNotFound, 404, ErrorCodeDeviceNotFound},
+		{"device exists", persistence.ErrDeviceExists, 409, ErrorCodeDeviceExists},
+		{"idempotency key reused", ErrIdempotencyKeyReused, 422, ErrorCodeIdempotencyConflict},
+		{"wrapped", fmt.Errorf("decoding: %w", ErrInvalidData), 400, ErrorCodeInvalidData},
+		{"unknown error", errors.New("disk full"), 500, ErrorCodeInternal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, httptest.NewRequest("GET", "/api/v0/signature-device/123", nil), test.err)
+			if w.Code != test.status {
+				t.Errorf("Expected status code %d, got %d", test.status, w.Code)
+			}
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Expected problem details, got %s", w.Header().Get("Content-Type"))
+			}
+			var problem ProblemDetails
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("Error while unmarshalling problem details: %v", err)
+			}
+			if problem.Code != test.code || problem.Status != test.status {
+				t.Errorf("Expected error code %s with status %d, got %s with %d", test.code, test.status, problem.Code, problem.Status)
+			}
+			if problem.Type != "about:blank" || problem.Title == "" {
+				t.Errorf("Expected problem type and title, got %s and %s", problem.Type, problem.Title)
+			}
+		})
+	}
+
+	t.Run("internal errors hide details", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteError(w, httptest.NewRequest("GET", "/", nil), errors.New("disk full"))
+		if bytes.Contains(w.Body.Bytes(), []byte("disk full")) {
+			t.Errorf("Expected internal error details to be hidden, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		WriteError(w, httptest.NewRequest("POST", "/api/v0/signature-device/sign-batch", nil), errors.Join(
+			&FieldError{Field: "data[0]", Err: ErrInvalidData},
+			&FieldError{Field: "data[1]", Err: ErrInvalidData},
+		))
+		var problem ProblemDetails
+		json.NewDecoder(w.Body).Decode(&problem)
+		if w.Code != 400 || len(problem.InvalidParams) != 2 {
+			t.Fatalf("Expected status code 400 with 2 invalid params, got %d with %v", w.Code, problem.InvalidParams)
+		}
+		if problem.InvalidParams[0].Name != "data[0]" || problem.InvalidParams[0].Reason != ErrInvalidData.Error() {
+			t.Errorf("Expected invalid param data[0], got %v", problem.InvalidParams[0])
+		}
+		if problem.Instance != "/api/v0/signature-device/sign-batch" {
+			t.Errorf("Expected instance to be the request path, got %s", problem.Instance)
+		}
+	})
+}
+
+func TestErrorStatusMapping(t *testing.T) {
+	s := NewServer(":8080")
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(CreateSignatureDeviceRequest{Algorithm: "DSA"})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device", bytes.NewBuffer(requestBody))
+		s.SignatureDevice(w, request)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("sign with unknown device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(SignDataRequest{Id: unknownDeviceId, Data: "test_data"})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+		s.SignData(w, request)
+
+		var problem ProblemDetails
+		json.NewDecoder(w.Body).Decode(&problem)
+		if w.Code != 404 || problem.Code != ErrorCodeDeviceNotFound {
+			t.Errorf("Expected status code 404 with code %s, got %d with %s", ErrorCodeDeviceNotFound, w.Code, problem.Code)
+		}
+	})
+}
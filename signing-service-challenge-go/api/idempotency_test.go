@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestSignData_Idempotency(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice(testDeviceId, "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+
+	sign := func(signDataRequest SignDataRequest, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		signDataRequest.Id = testDeviceId
+		requestBody, _ := json.Marshal(signDataRequest)
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+		if key != "" {
+			request.Header.Set("Idempotency-Key", key)
+		}
+		s.SignData(w, request)
+		return w
+	}
+
+	signatureCounter := func() int {
+		stored, _ := s.deviceRepository.FindById(testDeviceId)
+		return stored.SignatureCounter()
+	}
+
+	t.Run("retry is replayed", func(t *testing.T) {
+		first := sign(SignDataRequest{Data: "receipt"}, "key-1")
+		retry := sign(SignDataRequest{Data: "receipt"}, "key-1")
+		if first.Code != 200 || retry.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d and %d", first.Code, retry.Code)
+		}
+		if first.Body.String() != retry.Body.String() {
+			t.Errorf("Expected retry to replay %s, got %s", first.Body.String(), retry.Body.String())
+		}
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("Expected retry to be marked as replayed")
+		}
+		if counter := signatureCounter(); counter != 1 {
+			t.Errorf("Expected signature counter 1, got %d", counter)
+		}
+	})
+
+	t.Run("transaction id", func(t *testing.T) {
+		sign(SignDataRequest{Data: "receipt", TransactionId: "key-2"}, "")
+		retry := sign(SignDataRequest{Data: "receipt"}, "key-2")
+		if retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Error("Expected retry with header to replay the transaction id")
+		}
+		if counter := signatureCounter(); counter != 2 {
+			t.Errorf("Expected signature counter 2, got %d", counter)
+		}
+	})
+
+	t.Run("key reused for other data", func(t *testing.T) {
+		w := sign(SignDataRequest{Data: "other receipt"}, "key-1")
+		if w.Code != 422 {
+			t.Errorf("Expected status code 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("conflicting keys", func(t *testing.T) {
+		w := sign(SignDataRequest{Data: "receipt", TransactionId: "key-3"}, "key-4")
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("concurrent retries sign once", func(t *testing.T) {
+		before := signatureCounter()
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sign(SignDataRequest{Data: "receipt"}, "key-6")
+			}()
+		}
+		wg.Wait()
+		if counter := signatureCounter(); counter != before+1 {
+			t.Errorf("Expected signature counter %d, got %d", before+1, counter)
+		}
+	})
+
+	t.Run("key reused for a digest", func(t *testing.T) {
+		w := sign(SignDataRequest{Data: strings.Repeat("ab", 32), DigestEncoding: "hex"}, "key-1")
+		if w.Code != 422 {
+			t.Errorf("Expected status code 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("failed request is not stored", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(SignDataRequest{Id: unknownDeviceId, Data: "receipt"})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+		request.Header.Set("Idempotency-Key", "key-5")
+		s.SignData(w, request)
+		if w.Code != 404 {
+			t.Errorf("Expected status code 404, got %d", w.Code)
+		}
+	})
+}
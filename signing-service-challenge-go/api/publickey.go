@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+const (
+	ContentTypePEM = "application/x-pem-file"
+	ContentTypeDER = "application/octet-stream"
+	ContentTypeJWK = "application/jwk+json"
+)
+
+// Export the public key of a signature device as PEM, DER or JWK
+func (s *Server) getPublicKey(response http.ResponseWriter, request *http.Request, deviceId string) {
+	if request.Method != http.MethodGet {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	contentType := negotiatePublicKeyContentType(request.Header.Get("Accept"))
+	if contentType == "" {
+		WriteError(response, request, ErrNotAcceptable)
+		return
+	}
+
+	signatureDevice, err := s.deviceRepository.FindById(deviceId)
+	if err != nil {
+		log.Printf("Error while finding signature device: %v", err)
+		WriteError(response, request, err)
+		return
+	}
+
+	var publicKey []byte
+	switch contentType {
+	case ContentTypePEM:
+		publicKey, err = crypto.MarshalPublicKey(signatureDevice.PublicKey())
+	case ContentTypeDER:
+		publicKey, err = crypto.MarshalPublicKeyDER(signatureDevice.PublicKey())
+	case ContentTypeJWK:
+		var jwk *crypto.JWK
+		jwk, err = crypto.MarshalPublicKeyJWK(signatureDevice.Algorithm(), signatureDevice.PublicKey())
+		if err == nil {
+			jwk.KeyId = signatureDevice.Id
+			publicKey, err = json.Marshal(jwk)
+		}
+	}
+	if err != nil {
+		log.Printf("Error while marshalling public key: %v", err)
+		WriteInternalError(response)
+		return
+	}
+
+	response.Header().Set("Content-Type", contentType)
+	response.WriteHeader(http.StatusOK)
+	response.Write(publicKey)
+}
+
+// negotiatePublicKeyContentType picks the supported media type with the highest
+// quality in an Accept header, ties go to the media range listed first. Media
+// types with q=0 are never picked. PEM is used if the client accepts any media type.
+func negotiatePublicKeyContentType(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypePEM
+	}
+
+	best, bestQuality, bestPosition := "", 0.0, 0
+	for _, contentType := range []string{ContentTypePEM, ContentTypeDER, ContentTypeJWK} {
+		quality, position := acceptQuality(accept, contentType)
+		if quality > bestQuality || (quality > 0 && quality == bestQuality && position < bestPosition) {
+			best, bestQuality, bestPosition = contentType, quality, position
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality an Accept header assigns to a media type and
+// the position of the media range it was taken from. The most specific matching
+// media range applies, media ranges with an invalid quality are ignored.
+func acceptQuality(accept string, mediaType string) (float64, int) {
+	quality, position, specificity := 0.0, 0, -1
+	for i, mediaRange := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		var rangeSpecificity int
+		switch rangeType {
+		case mediaType:
+			rangeSpecificity = 2
+		case "application/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		rangeQuality := 1.0
+		if value, ok := params["q"]; ok {
+			rangeQuality, err = strconv.ParseFloat(value, 64)
+			if err != nil || rangeQuality < 0 || rangeQuality > 1 {
+				continue
+			}
+		}
+		quality, position, specificity = rangeQuality, i, rangeSpecificity
+	}
+	return quality, position
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestGetPublicKey(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+
+	getPublicKey := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/api/v0/signature-device/123/public-key", nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		s.SignatureDeviceResource(w, request)
+		return w
+	}
+
+	t.Run("PEM by default", func(t *testing.T) {
+		w := getPublicKey("")
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		if w.Header().Get("Content-Type") != ContentTypePEM {
+			t.Errorf("Expected content type %s, got %s", ContentTypePEM, w.Header().Get("Content-Type"))
+		}
+		block, _ := pem.Decode(w.Body.Bytes())
+		if block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatal("Expected a PEM encoded public key")
+		}
+		if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			t.Error("Error while parsing public key, got:", err)
+		}
+	})
+
+	t.Run("DER", func(t *testing.T) {
+		w := getPublicKey(ContentTypeDER)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		if _, err := x509.ParsePKIXPublicKey(w.Body.Bytes()); err != nil {
+			t.Error("Error while parsing public key, got:", err)
+		}
+	})
+
+	t.Run("JWK", func(t *testing.T) {
+		w := getPublicKey("text/html, " + ContentTypeJWK)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		var jwk crypto.JWK
+		if err := json.NewDecoder(w.Body).Decode(&jwk); err != nil {
+			t.Fatalf("Error while unmarshalling response body: %v", err)
+		}
+		if jwk.KeyType != "EC" || jwk.Curve != "P-384" || jwk.KeyId != "123" {
+			t.Errorf("Unexpected JWK %+v", jwk)
+		}
+	})
+
+	t.Run("quality values", func(t *testing.T) {
+		tests := []struct {
+			accept   string
+			expected string
+		}{
+			{ContentTypeJWK + ";q=0, " + ContentTypePEM, ContentTypePEM},
+			{ContentTypePEM + ";q=0.5, " + ContentTypeJWK, ContentTypeJWK},
+			{ContentTypeJWK + ", " + ContentTypePEM + ";q=1", ContentTypeJWK},
+			{ContentTypePEM + ";q=0, */*;q=0.1", ContentTypeDER},
+		}
+		for _, test := range tests {
+			w := getPublicKey(test.accept)
+			if w.Code != 200 || w.Header().Get("Content-Type") != test.expected {
+				t.Errorf("Expected %s for %q, got %d with %s", test.expected, test.accept, w.Code, w.Header().Get("Content-Type"))
+			}
+		}
+	})
+
+	t.Run("all formats refused", func(t *testing.T) {
+		w := getPublicKey(ContentTypeJWK + ";q=0, */*;q=0")
+		if w.Code != 406 {
+			t.Errorf("Expected status code 406, got %d", w.Code)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		w := getPublicKey("text/html")
+		if w.Code != 406 {
+			t.Errorf("Expected status code 406, got %d", w.Code)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
 )
 
@@ -12,23 +13,61 @@ type Response struct {
 	Data interface{} `json:"data"`
 }
 
-// ErrorResponse is the generic error API response container.
-type ErrorResponse struct {
-	Errors []string `json:"errors"`
+// ContentTypeProblem is the content type of error responses (RFC 7807)
+const ContentTypeProblem = "application/problem+json"
+
+// ProblemTypeBlank is the type of all problems (RFC 7807 section 4.2): a problem
+// has no semantics beyond its status code, the code extension tells problems apart
+const ProblemTypeBlank = "about:blank"
+
+// ProblemDetails is the generic error API response container (RFC 7807).
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is a machine readable error code, see the ErrorCode constants
+	Code string `json:"code"`
+	// InvalidParams lists the request fields that failed validation
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam describes why a single request field is invalid
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
 
 // Server manages HTTP requests and dispatches them to the appropriate services.
 type Server struct {
-	listenAddress    string
-	deviceRepository *persistence.InMemorySignatureDeviceRepository
+	listenAddress         string
+	deviceRepository      persistence.SignatureDeviceRepository
+	transactionRepository persistence.TransactionRepository
+	clock                 domain.Clock
 }
 
-// NewServer is a factory to instantiate a new Server.
+// NewServer is a factory to instantiate a new Server with in-memory repositories.
 func NewServer(listenAddress string) *Server {
-	deviceRepository := persistence.NewInMemorySignatureDeviceRepository()
+	transactionRepository := persistence.NewInMemoryTransactionRepository()
+	return NewServerWithRepositories(
+		listenAddress,
+		persistence.NewInMemorySignatureDeviceRepository(transactionRepository),
+		transactionRepository,
+	)
+}
+
+// NewServerWithRepositories is a factory to instantiate a new Server with the given repositories.
+func NewServerWithRepositories(
+	listenAddress string,
+	deviceRepository persistence.SignatureDeviceRepository,
+	transactionRepository persistence.TransactionRepository,
+) *Server {
 	return &Server{
-		listenAddress:    listenAddress,
-		deviceRepository: deviceRepository,
+		listenAddress:         listenAddress,
+		deviceRepository:      deviceRepository,
+		transactionRepository: transactionRepository,
+		clock:                 domain.SystemClock{},
 	}
 }
 
@@ -39,30 +78,38 @@ func (s *Server) Run() error {
 	mux.Handle("/api/v0/health", http.HandlerFunc(s.Health))
 	mux.Handle("/api/v0/signature-device", http.HandlerFunc(s.SignatureDevice))
 	mux.Handle("/api/v0/signature-device/sign", http.HandlerFunc(s.SignData))
+	mux.Handle("/api/v0/signature-device/sign-batch", http.HandlerFunc(s.SignBatch))
+	mux.Handle("/api/v0/signature-device/", http.HandlerFunc(s.SignatureDeviceResource))
 
 	return http.ListenAndServe(s.listenAddress, mux)
 }
 
 // WriteInternalError writes a default internal error message as an HTTP response.
 func WriteInternalError(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+	WriteProblem(w, NewProblemDetails(http.StatusInternalServerError, ErrorCodeInternal, ""))
 }
 
-// WriteErrorResponse takes an HTTP status code and a slice of errors
-// and writes those as an HTTP error response in a structured format.
-func WriteErrorResponse(w http.ResponseWriter, code int, errors []string) {
-	w.WriteHeader(code)
-
-	errorResponse := ErrorResponse{
-		Errors: errors,
+// NewProblemDetails creates the problem details of an error code
+func NewProblemDetails(status int, code string, detail string) ProblemDetails {
+	return ProblemDetails{
+		Type:   ProblemTypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
 	}
+}
 
-	bytes, err := json.Marshal(errorResponse)
+// WriteProblem writes problem details as an HTTP error response.
+func WriteProblem(w http.ResponseWriter, problem ProblemDetails) {
+	bytes, err := json.Marshal(problem)
 	if err != nil {
-		WriteInternalError(w)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(problem.Status)
 	w.Write(bytes)
 }
 
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+)
+
+const (
+	SignatureFormatDER = "der"
+	SignatureFormatRaw = "raw"
+
+	SignatureEncodingBase64    = "base64"
+	SignatureEncodingBase64URL = "base64url"
+
+	DigestEncodingHex    = "hex"
+	DigestEncodingBase64 = "base64"
+
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+type SignDataRequest struct {
+	Id   string `json:"id"`
+	Data string `json:"data"`
+	// DataEncoding is "utf8" (default) or "base64" for binary data
+	DataEncoding string `json:"data_encoding,omitempty"`
+	// DigestEncoding marks data as SHA-256 digest of the payload, encoded as "hex" or "base64"
+	DigestEncoding string `json:"digest_encoding,omitempty"`
+	// SignatureFormat is "der" (default) or "raw" for IEEE P1363 r||s ECDSA signatures
+	SignatureFormat string `json:"signature_format,omitempty"`
+	// SignatureEncoding is "base64" (default) or "base64url"
+	SignatureEncoding string `json:"signature_encoding,omitempty"`
+	// JWS requests the signed data as additional compact JWS
+	JWS bool `json:"jws,omitempty"`
+	// TransactionId makes retries idempotent like the Idempotency-Key header
+	TransactionId string `json:"transaction_id,omitempty"`
+}
+
+type SignDataResponse struct {
+	Signature  string `json:"signature"`
+	SignedData string `json:"signed_data"`
+	// SignedDataEncoding is "utf8" or "base64" and follows the encoding of the request data
+	SignedDataEncoding string `json:"signed_data_encoding"`
+	// Timestamp is the server time the data was signed at
+	Timestamp time.Time `json:"timestamp"`
+	// TimestampSigned is false for devices with legacy framing, whose signed data
+	// does not cover the timestamp
+	TimestampSigned bool   `json:"timestamp_signed"`
+	JWS             string `json:"jws,omitempty"`
+}
+
+// Sign data with a signature device
+func (s *Server) SignData(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	var signDataRequest SignDataRequest
+	err := decodeRequest(response, request, MaxRequestBodySize, &signDataRequest)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+
+	data, err := validateSignDataRequest(signDataRequest)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+	signDataRequest.Id = canonicalDeviceId(signDataRequest.Id)
+
+	key := request.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		key = signDataRequest.TransactionId
+	}
+	if signDataRequest.TransactionId != "" && signDataRequest.TransactionId != key {
+		WriteError(response, request, ErrConflictingIdempotencyKey)
+		return
+	}
+
+	signDataResponse, replayed, err := s.signData(signDataRequest, data, key)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+	if replayed {
+		response.Header().Set(IdempotentReplayedHeader, "true")
+	}
+	WriteAPIResponse(response, http.StatusOK, signDataResponse)
+}
+
+// errReplayed aborts a device update whose signature already exists
+var errReplayed = errors.New("signature was already created for the idempotency key")
+
+// signData signs the decoded data with the requested device, the device repository
+// records the transaction. If the idempotency key was already used for the same
+// data, the stored signature is returned instead and reported as replayed.
+func (s *Server) signData(signDataRequest SignDataRequest, data []byte, key string) (SignDataResponse, bool, error) {
+	var signature *domain.Signature
+	var publicKey gocrypto.PublicKey
+	options := domain.SignOptions{
+		Digest: signDataRequest.DigestEncoding != "",
+		JWS:    signDataRequest.JWS,
+		Clock:  s.clock,
+	}
+	err := s.deviceRepository.Update(signDataRequest.Id, func(signatureDevice *domain.SignatureDevice) ([]*domain.Signature, error) {
+		publicKey = signatureDevice.PublicKey()
+		// the lookup runs within the update, so that a concurrent retry cannot sign twice
+		if key != "" {
+			existing, err := s.transactionRepository.FindByIdempotencyKey(signatureDevice.Id, key)
+			if err == nil {
+				if !sameTransaction(existing, data, options) {
+					return nil, ErrIdempotencyKeyReused
+				}
+				signature = existing
+				return nil, errReplayed
+			}
+			if !errors.Is(err, persistence.ErrTransactionNotFound) {
+				return nil, err
+			}
+		}
+
+		var err error
+		signature, err = signatureDevice.SignWithOptions(data, options)
+		if err != nil {
+			return nil, err
+		}
+		signature.IdempotencyKey = key
+		return []*domain.Signature{signature}, nil
+	})
+	replayed := errors.Is(err, errReplayed)
+	if err != nil && !replayed {
+		return SignDataResponse{}, false, err
+	}
+
+	signDataResponse, err := newSignDataResponse(
+		publicKey,
+		signature,
+		signDataRequest.SignatureFormat,
+		signDataRequest.SignatureEncoding,
+		signDataRequest.DataEncoding,
+	)
+	return signDataResponse, replayed, err
+}
+
+// sameTransaction reports whether a stored signature was created for the same
+// data and options, it may be replayed in any signature format and encoding
+func sameTransaction(signature *domain.Signature, data []byte, options domain.SignOptions) bool {
+	dataMode := domain.DATA_MODE_RAW
+	if options.Digest {
+		dataMode = domain.DATA_MODE_SHA256_DIGEST
+	}
+	return bytes.Equal(signature.Data, data) && signature.DataMode == dataMode && (signature.JWS != "") == options.JWS
+}
+
+// newSignDataResponse encodes a signature and its signed data as requested
+func newSignDataResponse(
+	publicKey gocrypto.PublicKey,
+	signature *domain.Signature,
+	signatureFormat string,
+	signatureEncoding string,
+	dataEncoding string,
+) (SignDataResponse, error) {
+	encodedSignature, err := encodeSignature(publicKey, signature.Signature, signatureFormat, signatureEncoding)
+	if err != nil {
+		return SignDataResponse{}, fmt.Errorf("encoding signature: %w", err)
+	}
+	signedData, signedDataEncoding := encodeData(signature.Signed_Data, dataEncoding)
+	return SignDataResponse{
+		Signature:          encodedSignature,
+		SignedData:         signedData,
+		SignedDataEncoding: signedDataEncoding,
+		Timestamp:          signature.Timestamp,
+		TimestampSigned:    signature.TimestampSigned(),
+		JWS:                signature.JWS,
+	}, nil
+}
+
+// decodeSignData returns the bytes to be signed, either plain data or a hex encoded digest
+func decodeSignData(data string, dataEncoding string, digestEncoding string) ([]byte, error) {
+	if digestEncoding == "" {
+		return decodeData(data, dataEncoding)
+	}
+	if dataEncoding != "" {
+		return nil, ErrConflictingEncodings
+	}
+	return decodeDigest(data, digestEncoding)
+}
+
+// decodeDigest converts a digest to hex, which is how the device embeds digests
+// in the secured data. Digests that cannot be decoded or have the wrong length
+// are reported as domain.ErrInvalidDigest.
+func decodeDigest(data string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", DigestEncodingHex:
+		return domain.NormalizeDigest([]byte(data))
+	case DigestEncodingBase64:
+		digest, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, domain.ErrInvalidDigest
+		}
+		return domain.NormalizeDigest([]byte(hex.EncodeToString(digest)))
+	default:
+		return nil, ErrUnsupportedDigestEncoding
+	}
+}
+
+func validSignatureFormat(format string, encoding string) bool {
+	switch format {
+	case "", SignatureFormatDER, SignatureFormatRaw:
+	default:
+		return false
+	}
+	switch encoding {
+	case "", SignatureEncodingBase64, SignatureEncodingBase64URL:
+	default:
+		return false
+	}
+	return true
+}
+
+// decodeSignature converts a signature in the given format and encoding back into
+// the form the device verifies. Signatures that cannot be decoded are reported
+// as ErrInvalidSignatureEncoding, raw signatures of the wrong size as crypto.ErrInvalidSignature.
+func decodeSignature(publicKey gocrypto.PublicKey, signature string, format string, encoding string) ([]byte, error) {
+	base64Encoding := base64.StdEncoding
+	if encoding == SignatureEncodingBase64URL {
+		base64Encoding = base64.RawURLEncoding
+	}
+	decoded, err := base64Encoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	if format == SignatureFormatRaw {
+		return crypto.DecodeSignatureRaw(publicKey, decoded)
+	}
+	return decoded, nil
+}
+
+// encodeSignature converts a base64 encoded signature into the requested format and encoding
+func encodeSignature(publicKey gocrypto.PublicKey, signature string, format string, encoding string) (string, error) {
+	if format != SignatureFormatRaw && encoding != SignatureEncodingBase64URL {
+		return signature, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", err
+	}
+	if format == SignatureFormatRaw {
+		decoded, err = crypto.EncodeSignatureRaw(publicKey, decoded)
+		if err != nil {
+			return "", err
+		}
+	}
+	if encoding == SignatureEncodingBase64URL {
+		return base64.RawURLEncoding.EncodeToString(decoded), nil
+	}
+	return base64.StdEncoding.EncodeToString(decoded), nil
+}
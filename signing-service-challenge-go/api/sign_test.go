@@ -0,0 +1,228 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestSignData_SignatureFormats(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSignerWithParameters("ECC", crypto.KeyParameters{Curve: "P-256"}, crypto.SignatureScheme{})
+	s.deviceRepository.Save(domain.NewSignatureDevice(testDeviceId, "test_device", signer))
+
+	t.Run("raw base64url", func(t *testing.T) {
+		w, signDataResponse := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data", SignatureFormat: "raw", SignatureEncoding: "base64url"})
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(signDataResponse.Signature)
+		if err != nil {
+			t.Fatal("Expected base64url encoded signature, got:", err)
+		}
+		if len(raw) != 64 {
+			t.Errorf("Expected raw P-256 signature of 64 bytes, got %d", len(raw))
+		}
+	})
+
+	t.Run("JWS", func(t *testing.T) {
+		w, signDataResponse := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data", JWS: true})
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		parts := strings.Split(signDataResponse.JWS, ".")
+		if len(parts) != 3 {
+			t.Fatalf("Expected compact JWS, got %s", signDataResponse.JWS)
+		}
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if string(payload) != signDataResponse.SignedData {
+			t.Errorf("Expected JWS payload %s, got %s", signDataResponse.SignedData, payload)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		w, _ := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data", SignatureFormat: "pem"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestSignData_Digest(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	s.deviceRepository.Save(domain.NewSignatureDevice(testDeviceId, "test_device", signer))
+	digest := sha256.Sum256([]byte("test_data"))
+
+	sign := func(data string, encoding string) int {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(SignDataRequest{Id: testDeviceId, Data: data, DigestEncoding: encoding})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+		s.SignData(w, request)
+		return w.Code
+	}
+
+	t.Run("hex", func(t *testing.T) {
+		if code := sign(hex.EncodeToString(digest[:]), "hex"); code != 200 {
+			t.Errorf("Expected status code 200, got %d", code)
+		}
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		if code := sign(base64.StdEncoding.EncodeToString(digest[:]), "base64"); code != 200 {
+			t.Errorf("Expected status code 200, got %d", code)
+		}
+	})
+
+	t.Run("wrong length", func(t *testing.T) {
+		if code := sign("abcd", "hex"); code != 400 {
+			t.Errorf("Expected status code 400, got %d", code)
+		}
+	})
+
+	t.Run("invalid digests are reported alike", func(t *testing.T) {
+		for _, request := range []SignDataRequest{
+			{Id: testDeviceId, Data: "zz", DigestEncoding: "hex"},
+			{Id: testDeviceId, Data: "!", DigestEncoding: "base64"},
+			{Id: testDeviceId, Data: base64.StdEncoding.EncodeToString(digest[:4]), DigestEncoding: "base64"},
+		} {
+			w := httptest.NewRecorder()
+			requestBody, _ := json.Marshal(request)
+			s.SignData(w, httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody)))
+			var problem ProblemDetails
+			json.NewDecoder(w.Body).Decode(&problem)
+			if w.Code != 400 || problem.Code != ErrorCodeInvalidDigest {
+				t.Errorf("Expected status code 400 with code %s for %q, got %d with %s", ErrorCodeInvalidDigest, request.Data, w.Code, problem.Code)
+			}
+		}
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		if code := sign(hex.EncodeToString(digest[:]), "base32"); code != 400 {
+			t.Errorf("Expected status code 400, got %d", code)
+		}
+	})
+
+	t.Run("transactions record data mode", func(t *testing.T) {
+		signatures, _ := s.transactionRepository.FindByDeviceId(testDeviceId)
+		if len(signatures) != 2 {
+			t.Fatalf("Expected 2 transactions, got %d", len(signatures))
+		}
+		if signatures[1].DataMode != domain.DATA_MODE_SHA256_DIGEST {
+			t.Error("Expected digest data mode, but got", signatures[1].DataMode)
+		}
+	})
+}
+
+func TestSignData_BinaryData(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	s.deviceRepository.Save(domain.NewSignatureDevice(testDeviceId, "test_device", signer))
+	data := []byte{0x00, 0xff, '_', 0xc3, 0x28}
+
+	t.Run("base64 data", func(t *testing.T) {
+		w, signDataResponse := requestSignature(s, SignDataRequest{
+			Id:           testDeviceId,
+			Data:         base64.StdEncoding.EncodeToString(data),
+			DataEncoding: "base64",
+		})
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		if signDataResponse.SignedDataEncoding != "base64" {
+			t.Fatalf("Expected signed data encoding base64, got %s", signDataResponse.SignedDataEncoding)
+		}
+		signedData, _ := base64.StdEncoding.DecodeString(signDataResponse.SignedData)
+		if !bytes.HasPrefix(signedData, append([]byte("0_"), data...)) {
+			t.Errorf("Expected signed data to embed the exact bytes, got %v", signedData)
+		}
+	})
+
+	t.Run("transactions keep the exact bytes", func(t *testing.T) {
+		signatures, _ := s.transactionRepository.FindByDeviceId(testDeviceId)
+		if len(signatures) != 1 || !bytes.Equal(signatures[0].Data, data) {
+			t.Errorf("Expected stored data %v, got %v", data, signatures)
+		}
+	})
+
+	t.Run("utf8 data", func(t *testing.T) {
+		_, signDataResponse := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data"})
+		if signDataResponse.SignedDataEncoding != "utf8" {
+			t.Errorf("Expected signed data encoding utf8, got %s", signDataResponse.SignedDataEncoding)
+		}
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		w, _ := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "not base64!", DataEncoding: "base64"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		w, _ := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data", DataEncoding: "latin1"})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestSignData_Timestamp(t *testing.T) {
+	s := NewServer(":8080")
+	clock := domain.FixedClock{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	s.clock = clock
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice, _ := domain.NewSignatureDeviceWithFraming(testDeviceId, "test_device", signer, domain.FRAMING_V1)
+	s.deviceRepository.Save(signatureDevice)
+
+	_, signDataResponse := requestSignature(s, SignDataRequest{Id: testDeviceId, Data: "test_data"})
+	if !signDataResponse.Timestamp.Equal(clock.Time) || !signDataResponse.TimestampSigned {
+		t.Errorf("Expected signed timestamp %v, got %v", clock.Time, signDataResponse.Timestamp)
+	}
+
+	securedData, err := domain.ParseSecuredData([]byte(signDataResponse.SignedData))
+	if err != nil {
+		t.Fatalf("Error while parsing signed data: %v", err)
+	}
+	if !securedData.Timestamp.Equal(clock.Time) {
+		t.Errorf("Expected signed timestamp %v, got %v", clock.Time, securedData.Timestamp)
+	}
+
+	signatures, _ := s.transactionRepository.FindByDeviceId(testDeviceId)
+	if len(signatures) != 1 || !signatures[0].Timestamp.Equal(clock.Time) {
+		t.Errorf("Expected stored transaction with timestamp %v", clock.Time)
+	}
+
+	t.Run("legacy framing", func(t *testing.T) {
+		legacyDeviceId := "0c6f2a4e-3b1d-4e8a-9f27-5d4c3b2a1908"
+		s.deviceRepository.Save(domain.NewSignatureDevice(legacyDeviceId, "legacy_device", signer))
+
+		_, signDataResponse := requestSignature(s, SignDataRequest{Id: legacyDeviceId, Data: "test_data"})
+		if signDataResponse.TimestampSigned {
+			t.Error("Expected the timestamp of a legacy signature to be marked as not signed")
+		}
+	})
+}
+
+// requestSignature sends a sign request to the server and decodes the response
+func requestSignature(s *Server, signDataRequest SignDataRequest) (*httptest.ResponseRecorder, SignDataResponse) {
+	w := httptest.NewRecorder()
+	requestBody, _ := json.Marshal(signDataRequest)
+	request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+	s.SignData(w, request)
+
+	var responseBody struct {
+		Data SignDataResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&responseBody)
+	return w, responseBody.Data
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+type Transaction struct {
+	Counter  int    `json:"counter"`
+	Data     string `json:"data"`
+	DataMode string `json:"data_mode"`
+	// DataEncoding is "utf8" or "base64" and applies to both data and signed_data
+	DataEncoding string    `json:"data_encoding"`
+	SignedData   string    `json:"signed_data"`
+	Signature    string    `json:"signature"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// List all signatures created by a signature device
+func (s *Server) listTransactions(response http.ResponseWriter, request *http.Request, deviceId string) {
+	if request.Method != http.MethodGet {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	_, err := s.deviceRepository.FindById(deviceId)
+	if err != nil {
+		log.Printf("Error while finding signature device: %v", err)
+		WriteError(response, request, err)
+		return
+	}
+
+	signatures, err := s.transactionRepository.FindByDeviceId(deviceId)
+	if err != nil {
+		log.Printf("Error while finding transactions: %v", err)
+		WriteInternalError(response)
+		return
+	}
+
+	transactions := make([]*Transaction, 0, len(signatures))
+	for _, signature := range signatures {
+		signedData, encoding := encodeData(signature.Signed_Data, DataEncodingUTF8)
+		data, _ := encodeData(signature.Data, encoding)
+		transactions = append(transactions, &Transaction{
+			Counter:      signature.Counter,
+			Data:         data,
+			DataMode:     signature.DataMode,
+			DataEncoding: encoding,
+			SignedData:   signedData,
+			Signature:    signature.Signature,
+			Timestamp:    signature.Timestamp,
+		})
+	}
+
+	WriteAPIResponse(response, http.StatusOK, transactions)
+}
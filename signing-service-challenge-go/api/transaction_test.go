@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestListTransactions(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice(testDeviceId, "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+
+	for _, data := range []string{"first", "second"} {
+		requestBody, _ := json.Marshal(SignDataRequest{Id: testDeviceId, Data: data})
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/sign", bytes.NewBuffer(requestBody))
+		s.SignData(httptest.NewRecorder(), request)
+	}
+
+	t.Run("list transactions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/api/v0/signature-device/"+testDeviceId+"/transactions", nil)
+		s.SignatureDeviceResource(w, request)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		var responseBody struct {
+			Data []Transaction `json:"data"`
+		}
+		err := json.NewDecoder(w.Body).Decode(&responseBody)
+		if err != nil {
+			t.Fatalf("Error while unmarshalling response body: %v", err)
+		}
+		if len(responseBody.Data) != 2 {
+			t.Fatalf("Expected 2 transactions, got %d", len(responseBody.Data))
+		}
+		for i, transaction := range responseBody.Data {
+			if transaction.Counter != i {
+				t.Errorf("Expected transaction counter %d, got %d", i, transaction.Counter)
+			}
+		}
+		if responseBody.Data[1].Data != "second" {
+			t.Errorf("Expected data of second transaction to be second, got %s", responseBody.Data[1].Data)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "/api/v0/signature-device/456/transactions", nil)
+		s.SignatureDeviceResource(w, request)
+
+		if w.Code != 404 {
+			t.Errorf("Expected status code 404, got %d", w.Code)
+		}
+	})
+}
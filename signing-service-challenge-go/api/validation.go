@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxRequestBodySize limits the size of JSON request bodies
+	MaxRequestBodySize = 1 << 20
+	// MaxBatchRequestBodySize limits the size of batch signing request bodies
+	MaxBatchRequestBodySize = 16 << 20
+	// MaxLabelLength is the maximum number of characters of a device label
+	MaxLabelLength = 255
+)
+
+// Validation errors, reported per field as FieldError
+var (
+	ErrRequestBodyTooLarge = errors.New("request body is too large")
+	ErrUnknownField        = errors.New("unknown field")
+	ErrMissingField        = errors.New("field is required")
+	ErrLabelTooLong        = fmt.Errorf("label must not be longer than %d characters", MaxLabelLength)
+	ErrInvalidLabel        = errors.New("label must only contain printable characters")
+)
+
+// decodeRequest decodes a JSON request body of limited size and rejects unknown fields
+func decodeRequest(response http.ResponseWriter, request *http.Request, limit int64, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(response, request.Body, limit))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil {
+		if _, err := decoder.Token(); err != io.EOF {
+			return ErrInvalidRequestBody
+		}
+		return nil
+	}
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return ErrRequestBodyTooLarge
+	}
+	// the json package offers no typed error for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &FieldError{Field: strings.Trim(field, `"`), Err: ErrUnknownField}
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidRequestBody, err)
+}
+
+// validator collects all violations of a request
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(valid bool, field string, err error) {
+	if !valid {
+		v.errs = append(v.errs, &FieldError{Field: field, Err: err})
+	}
+}
+
+// err returns all violations joined, or nil for a valid request
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+func validUUID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// canonicalDeviceId returns a valid UUID in the canonical lowercase form device
+// ids are stored in, so that the device is found for any accepted form of its id
+func canonicalDeviceId(id string) string {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return id
+	}
+	return parsed.String()
+}
+
+func validLabel(label string) bool {
+	if !utf8.ValidString(label) {
+		return false
+	}
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateCreateSignatureDeviceRequest(createSignatureDeviceRequest CreateSignatureDeviceRequest) error {
+	var v validator
+	v.check(createSignatureDeviceRequest.Id == "" || validUUID(createSignatureDeviceRequest.Id), "id", ErrInvalidDeviceId)
+	v.check(utf8.RuneCountInString(createSignatureDeviceRequest.Label) <= MaxLabelLength, "label", ErrLabelTooLong)
+	v.check(validLabel(createSignatureDeviceRequest.Label), "label", ErrInvalidLabel)
+	if createSignatureDeviceRequest.Algorithm == "" {
+		v.check(false, "algorithm", ErrMissingField)
+	} else {
+		_, err := crypto.GetAlgorithm(createSignatureDeviceRequest.Algorithm)
+		v.check(err == nil, "algorithm", crypto.ErrUnknownAlgorithm)
+	}
+	switch createSignatureDeviceRequest.Framing {
+	case "", domain.FRAMING_LEGACY, domain.FRAMING_V1:
+	default:
+		v.check(false, "framing", domain.ErrUnsupportedFraming)
+	}
+	return v.err()
+}
+
+// validateSignDataRequest validates a sign request and returns the decoded data to be signed
+func validateSignDataRequest(signDataRequest SignDataRequest) ([]byte, error) {
+	var v validator
+	validateDeviceId(&v, signDataRequest.Id)
+	validateEncodings(&v, signDataRequest.DataEncoding, signDataRequest.DigestEncoding, signDataRequest.SignatureFormat, signDataRequest.SignatureEncoding)
+	data := validateData(&v, "data", signDataRequest.Data, signDataRequest.DataEncoding, signDataRequest.DigestEncoding)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// validateSignBatchRequest validates a batch request and returns the decoded data to be signed
+func validateSignBatchRequest(signBatchRequest SignBatchRequest) ([][]byte, error) {
+	var v validator
+	validateDeviceId(&v, signBatchRequest.Id)
+	validateEncodings(&v, signBatchRequest.DataEncoding, signBatchRequest.DigestEncoding, signBatchRequest.SignatureFormat, signBatchRequest.SignatureEncoding)
+	v.check(len(signBatchRequest.Data) > 0 && len(signBatchRequest.Data) <= MaxBatchSize, "data",
+		fmt.Errorf("%w: batch must contain between 1 and %d payloads", ErrInvalidBatchSize, MaxBatchSize))
+	batch := make([][]byte, 0, len(signBatchRequest.Data))
+	for i, data := range signBatchRequest.Data {
+		batch = append(batch, validateData(&v, fmt.Sprintf("data[%d]", i), data, signBatchRequest.DataEncoding, signBatchRequest.DigestEncoding))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func validateDeviceId(v *validator, id string) {
+	if id == "" {
+		v.check(false, "id", ErrMissingField)
+		return
+	}
+	v.check(validUUID(id), "id", ErrInvalidDeviceId)
+}
+
+func validateEncodings(v *validator, dataEncoding string, digestEncoding string, signatureFormat string, signatureEncoding string) {
+	switch dataEncoding {
+	case "", DataEncodingUTF8, DataEncodingBase64:
+	default:
+		v.check(false, "data_encoding", ErrUnsupportedDataEncoding)
+	}
+	switch digestEncoding {
+	case "", DigestEncodingHex, DigestEncodingBase64:
+	default:
+		v.check(false, "digest_encoding", ErrUnsupportedDigestEncoding)
+	}
+	v.check(dataEncoding == "" || digestEncoding == "", "data_encoding", ErrConflictingEncodings)
+	v.check(validSignatureFormat(signatureFormat, ""), "signature_format", ErrUnsupportedFormat)
+	v.check(validSignatureFormat("", signatureEncoding), "signature_encoding", ErrUnsupportedFormat)
+}
+
+// validateData decodes the data to be signed and reports empty data and data that
+// cannot be decoded, unsupported encodings are already reported by validateEncodings.
+// Digests are checked by decodeDigest.
+func validateData(v *validator, field string, data string, dataEncoding string, digestEncoding string) []byte {
+	if data == "" {
+		v.check(false, field, ErrMissingField)
+		return nil
+	}
+	decoded, err := decodeSignData(data, dataEncoding, digestEncoding)
+	if errors.Is(err, ErrUnsupportedDataEncoding) || errors.Is(err, ErrUnsupportedDigestEncoding) || errors.Is(err, ErrConflictingEncodings) {
+		return nil
+	}
+	v.check(err == nil, field, err)
+	return decoded
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testDeviceId    = "4f0c1b52-7a0e-4d8e-9b3a-2f6c5d7e8a91"
+	unknownDeviceId = "9d3e6a27-5b1c-4f08-a2d4-7c8e1f0b3a56"
+)
+
+func TestRequestValidation(t *testing.T) {
+	s := NewServer(":8080")
+
+	send := func(handler func(w *httptest.ResponseRecorder, body string), body string) (*httptest.ResponseRecorder, ProblemDetails) {
+		w := httptest.NewRecorder()
+		handler(w, body)
+		var problem ProblemDetails
+		json.NewDecoder(w.Body).Decode(&problem)
+		return w, problem
+	}
+	create := func(w *httptest.ResponseRecorder, body string) {
+		s.SignatureDevice(w, httptest.NewRequest("POST", "/api/v0/signature-device", strings.NewReader(body)))
+	}
+	sign := func(w *httptest.ResponseRecorder, body string) {
+		s.SignData(w, httptest.NewRequest("POST", "/api/v0/signature-device/sign", strings.NewReader(body)))
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		w, problem := send(create, `{"algorithm": "ECC", "algorythm": "RSA"}`)
+		if w.Code != 400 || len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "algorythm" {
+			t.Errorf("Expected status code 400 with invalid param algorythm, got %d with %v", w.Code, problem.InvalidParams)
+		}
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		w, _ := send(create, `{"algorithm": "ECC"} {"algorithm": "RSA"}`)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		requestBody, _ := json.Marshal(SignDataRequest{Id: testDeviceId, Data: strings.Repeat("a", MaxRequestBodySize)})
+		w, problem := send(sign, string(requestBody))
+		if w.Code != 413 || problem.Code != ErrorCodeRequestTooLarge {
+			t.Errorf("Expected status code 413 with code %s, got %d with %s", ErrorCodeRequestTooLarge, w.Code, problem.Code)
+		}
+	})
+
+	t.Run("invalid labels", func(t *testing.T) {
+		for _, label := range []string{strings.Repeat("a", MaxLabelLength+1), "line\nbreak", "bell\a"} {
+			requestBody, _ := json.Marshal(CreateSignatureDeviceRequest{Label: label, Algorithm: "ECC"})
+			w, problem := send(create, string(requestBody))
+			if w.Code != 400 || len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "label" {
+				t.Errorf("Expected status code 400 with invalid param label for %q, got %d with %v", label, w.Code, problem.InvalidParams)
+			}
+		}
+	})
+
+	t.Run("valid label", func(t *testing.T) {
+		requestBody, _ := json.Marshal(CreateSignatureDeviceRequest{Label: strings.Repeat("ü", MaxLabelLength), Algorithm: "ECC"})
+		w, _ := send(create, string(requestBody))
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+	})
+
+	t.Run("all create violations are reported", func(t *testing.T) {
+		requestBody, _ := json.Marshal(CreateSignatureDeviceRequest{Id: "device-1", Label: "tab\t"})
+		w, problem := send(create, string(requestBody))
+		names := invalidParamNames(problem)
+		if w.Code != 400 || names != "id,label,algorithm" {
+			t.Errorf("Expected status code 400 with invalid params id,label,algorithm, got %d with %s", w.Code, names)
+		}
+	})
+
+	t.Run("all sign violations are reported", func(t *testing.T) {
+		w, problem := send(sign, `{"data_encoding": "hex", "signature_format": "pem"}`)
+		names := invalidParamNames(problem)
+		if w.Code != 400 || names != "id,data_encoding,signature_format,data" {
+			t.Errorf("Expected status code 400 with invalid params id,data_encoding,signature_format,data, got %d with %s", w.Code, names)
+		}
+	})
+
+	t.Run("invalid device id", func(t *testing.T) {
+		w, problem := send(sign, `{"id": "123", "data": "test_data"}`)
+		if w.Code != 400 || len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "id" {
+			t.Errorf("Expected status code 400 with invalid param id, got %d with %v", w.Code, problem.InvalidParams)
+		}
+	})
+
+	t.Run("uppercase device id", func(t *testing.T) {
+		id := strings.ToUpper(testDeviceId)
+		w, _ := send(create, `{"id": "`+id+`", "algorithm": "ECC"}`)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		w, _ = send(sign, `{"id": "`+id+`", "data": "test_data"}`)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid digest", func(t *testing.T) {
+		w, problem := send(sign, `{"id": "`+testDeviceId+`", "data": "abcd", "digest_encoding": "hex"}`)
+		if w.Code != 400 || problem.Code != ErrorCodeInvalidDigest {
+			t.Errorf("Expected status code 400 with code %s, got %d with %s", ErrorCodeInvalidDigest, w.Code, problem.Code)
+		}
+	})
+
+	t.Run("batch with missing payloads", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(SignBatchRequest{Id: testDeviceId, Data: []string{"first", ""}})
+		s.SignBatch(w, httptest.NewRequest("POST", "/api/v0/signature-device/sign-batch", bytes.NewBuffer(requestBody)))
+		var problem ProblemDetails
+		json.NewDecoder(w.Body).Decode(&problem)
+		if w.Code != 400 || invalidParamNames(problem) != "data[1]" {
+			t.Errorf("Expected status code 400 with invalid param data[1], got %d with %v", w.Code, problem.InvalidParams)
+		}
+	})
+}
+
+func invalidParamNames(problem ProblemDetails) string {
+	names := make([]string, 0, len(problem.InvalidParams))
+	for _, invalidParam := range problem.InvalidParams {
+		names = append(names, invalidParam.Name)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+type VerifySignatureRequest struct {
+	SignedData string `json:"signed_data"`
+	// SignedDataEncoding is "utf8" (default) or "base64" for binary signed data
+	SignedDataEncoding string `json:"signed_data_encoding,omitempty"`
+	Signature          string `json:"signature"`
+	// SignatureFormat and SignatureEncoding describe the signature as for signing
+	SignatureFormat   string `json:"signature_format,omitempty"`
+	SignatureEncoding string `json:"signature_encoding,omitempty"`
+}
+
+type VerifySignatureResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// Verify a signature against the key of a signature device
+func (s *Server) verifySignature(response http.ResponseWriter, request *http.Request, deviceId string) {
+	if request.Method != http.MethodPost {
+		WriteError(response, request, ErrMethodNotAllowed)
+		return
+	}
+
+	var verifySignatureRequest VerifySignatureRequest
+	err := decodeRequest(response, request, MaxRequestBodySize, &verifySignatureRequest)
+	if err != nil {
+		WriteError(response, request, err)
+		return
+	}
+
+	signedData, err := decodeData(verifySignatureRequest.SignedData, verifySignatureRequest.SignedDataEncoding)
+	if err != nil {
+		WriteError(response, request, &FieldError{Field: "signed_data", Err: err})
+		return
+	}
+
+	if !validSignatureFormat(verifySignatureRequest.SignatureFormat, "") {
+		WriteError(response, request, &FieldError{Field: "signature_format", Err: ErrUnsupportedFormat})
+		return
+	}
+	if !validSignatureFormat("", verifySignatureRequest.SignatureEncoding) {
+		WriteError(response, request, &FieldError{Field: "signature_encoding", Err: ErrUnsupportedFormat})
+		return
+	}
+
+	signatureDevice, err := s.deviceRepository.FindById(deviceId)
+	if err != nil {
+		log.Printf("Error while finding signature device: %v", err)
+		WriteError(response, request, err)
+		return
+	}
+
+	signature, err := decodeSignature(
+		signatureDevice.PublicKey(),
+		verifySignatureRequest.Signature,
+		verifySignatureRequest.SignatureFormat,
+		verifySignatureRequest.SignatureEncoding,
+	)
+	if errors.Is(err, ErrInvalidSignatureEncoding) {
+		WriteError(response, request, &FieldError{Field: "signature", Err: err})
+		return
+	}
+	if err == nil {
+		err = signatureDevice.Verify(signedData, signature)
+	}
+	if err != nil && !errors.Is(err, crypto.ErrInvalidSignature) {
+		log.Printf("Error while verifying signature: %v", err)
+		WriteInternalError(response)
+		return
+	}
+
+	WriteAPIResponse(response, http.StatusOK, VerifySignatureResponse{
+		Valid: err == nil,
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestVerifySignature(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("RSA")
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+	signature, _ := signatureDevice.Sign("test_data")
+
+	verify := func(verifySignatureRequest VerifySignatureRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		requestBody, _ := json.Marshal(verifySignatureRequest)
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/123/verify", bytes.NewBuffer(requestBody))
+		s.SignatureDeviceResource(w, request)
+		return w
+	}
+
+	tests := []struct {
+		name       string
+		signedData string
+		valid      bool
+	}{
+		{"valid signature", string(signature.Signed_Data), true},
+		{"tampered data", string(signature.Signed_Data) + "x", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := verify(VerifySignatureRequest{
+				SignedData: test.signedData,
+				Signature:  signature.Signature,
+			})
+			if w.Code != 200 {
+				t.Fatalf("Expected status code 200, got %d", w.Code)
+			}
+			var responseBody struct {
+				Data VerifySignatureResponse `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&responseBody); err != nil {
+				t.Fatalf("Error while unmarshalling response body: %v", err)
+			}
+			if responseBody.Data.Valid != test.valid {
+				t.Errorf("Expected valid to be %v, got %v", test.valid, responseBody.Data.Valid)
+			}
+		})
+	}
+
+	t.Run("invalid base64", func(t *testing.T) {
+		w := verify(VerifySignatureRequest{
+			SignedData: string(signature.Signed_Data),
+			Signature:  "not base64!",
+		})
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestVerifySignature_SignatureFormats(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSignerWithParameters("ECC", crypto.KeyParameters{Curve: "P-256"}, crypto.SignatureScheme{})
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+	signature, _ := signatureDevice.Sign("test_data")
+	raw, _ := encodeSignature(signatureDevice.PublicKey(), signature.Signature, SignatureFormatRaw, SignatureEncodingBase64URL)
+
+	verify := func(verifySignatureRequest VerifySignatureRequest) (int, bool) {
+		w := httptest.NewRecorder()
+		verifySignatureRequest.SignedData = string(signature.Signed_Data)
+		requestBody, _ := json.Marshal(verifySignatureRequest)
+		request := httptest.NewRequest("POST", "/api/v0/signature-device/123/verify", bytes.NewBuffer(requestBody))
+		s.SignatureDeviceResource(w, request)
+
+		var responseBody struct {
+			Data VerifySignatureResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&responseBody)
+		return w.Code, responseBody.Data.Valid
+	}
+
+	t.Run("raw base64url", func(t *testing.T) {
+		code, valid := verify(VerifySignatureRequest{Signature: raw, SignatureFormat: "raw", SignatureEncoding: "base64url"})
+		if code != 200 || !valid {
+			t.Errorf("Expected valid raw signature, got status code %d", code)
+		}
+	})
+
+	t.Run("raw signature of wrong size", func(t *testing.T) {
+		code, valid := verify(VerifySignatureRequest{Signature: raw[:20], SignatureFormat: "raw", SignatureEncoding: "base64url"})
+		if code != 200 || valid {
+			t.Errorf("Expected invalid signature, got status code %d", code)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		code, _ := verify(VerifySignatureRequest{Signature: raw, SignatureFormat: "pem"})
+		if code != 400 {
+			t.Errorf("Expected status code 400, got %d", code)
+		}
+	})
+}
+
+func TestVerifySignature_BinaryData(t *testing.T) {
+	s := NewServer(":8080")
+	signer, _ := crypto.CreateSigner("ECC")
+	signatureDevice := domain.NewSignatureDevice("123", "test_device", signer)
+	s.deviceRepository.Save(signatureDevice)
+	signature, _ := signatureDevice.SignWithOptions([]byte{0x00, 0xff, 0xfe}, domain.SignOptions{})
+
+	w := httptest.NewRecorder()
+	requestBody, _ := json.Marshal(VerifySignatureRequest{
+		SignedData:         base64.StdEncoding.EncodeToString(signature.Signed_Data),
+		SignedDataEncoding: "base64",
+		Signature:          signature.Signature,
+	})
+	request := httptest.NewRequest("POST", "/api/v0/signature-device/123/verify", bytes.NewBuffer(requestBody))
+	s.SignatureDeviceResource(w, request)
+
+	var responseBody struct {
+		Data VerifySignatureResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&responseBody)
+	if w.Code != 200 || !responseBody.Data.Valid {
+		t.Errorf("Expected valid signature over binary data, got status code %d", w.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package crypto
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// To add a new algorithm, add a file that implements the Signer, Verifier and
+// KeyMarshaler interfaces and the JWK encoding for its keys and registers them
+// with RegisterAlgorithm in an init function. See rsa.go and ecdsa.go for examples.
+
+// ErrUnknownAlgorithm is an error for unknown algorithms.
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+// ErrInvalidAlgorithm is an error for algorithms that cannot be registered.
+var ErrInvalidAlgorithm = errors.New("invalid algorithm")
+
+// ErrAlgorithmExists is an error for registering an algorithm name twice.
+var ErrAlgorithmExists = errors.New("algorithm already registered")
+
+// ErrInvalidKeyParameters is an error for key parameters rejected by the key policy of an algorithm.
+var ErrInvalidKeyParameters = errors.New("invalid key parameters")
+
+// KeyParameters configures the key generation of an algorithm.
+// Parameters that do not apply to an algorithm are left empty.
+type KeyParameters struct {
+	// Size is the RSA modulus size in bits.
+	Size int
+	// Curve is the name of the elliptic curve, e.g. P-256.
+	Curve string
+}
+
+// Algorithm bundles everything needed to create, use and store the keys of a signature algorithm.
+type Algorithm struct {
+	// Name identifies the algorithm, e.g. in API requests and stored records.
+	Name string
+	// Parameters checks requested key parameters against the key policy of the
+	// algorithm and returns them with defaults filled in.
+	Parameters func(requested KeyParameters) (KeyParameters, error)
+	// Scheme checks a requested signature scheme against the schemes supported
+	// by the algorithm and returns it with defaults filled in.
+	Scheme func(requested SignatureScheme) (SignatureScheme, error)
+	// Generate generates a new key pair with validated parameters and returns a
+	// Signer for it that uses the given signature scheme.
+	Generate func(parameters KeyParameters, scheme SignatureScheme) (Signer, error)
+	// Verifier creates a Verifier for a public key and signature scheme of the algorithm.
+	Verifier func(publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error)
+	// Marshaler encodes and decodes the keys of the algorithm.
+	Marshaler KeyMarshaler
+	// JWK converts a public key of the algorithm into its JWK representation.
+	JWK func(publicKey crypto.PublicKey) (*JWK, error)
+}
+
+// validate checks that all fields of an algorithm are set.
+func (a Algorithm) validate() error {
+	missing := ""
+	switch {
+	case a.Name == "":
+		missing = "Name"
+	case a.Parameters == nil:
+		missing = "Parameters"
+	case a.Scheme == nil:
+		missing = "Scheme"
+	case a.Generate == nil:
+		missing = "Generate"
+	case a.Verifier == nil:
+		missing = "Verifier"
+	case a.Marshaler == nil:
+		missing = "Marshaler"
+	case a.JWK == nil:
+		missing = "JWK"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s is missing", ErrInvalidAlgorithm, missing)
+}
+
+var (
+	algorithms   = make(map[string]Algorithm)
+	algorithmsMu sync.RWMutex
+)
+
+// RegisterAlgorithm makes an algorithm available under its name.
+// Algorithms with missing fields or an already registered name are rejected.
+func RegisterAlgorithm(algorithm Algorithm) error {
+	if err := algorithm.validate(); err != nil {
+		return err
+	}
+
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+	if _, ok := algorithms[algorithm.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrAlgorithmExists, algorithm.Name)
+	}
+	algorithms[algorithm.Name] = algorithm
+	return nil
+}
+
+// mustRegisterAlgorithm registers a built-in algorithm and panics if it is rejected.
+func mustRegisterAlgorithm(algorithm Algorithm) {
+	if err := RegisterAlgorithm(algorithm); err != nil {
+		panic(err)
+	}
+}
+
+// GetAlgorithm returns the algorithm registered under the given name.
+func GetAlgorithm(name string) (*Algorithm, error) {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	algorithm, ok := algorithms[name]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	return &algorithm, nil
+}
+
+// Algorithms returns the sorted names of all registered algorithms.
+func Algorithms() []string {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,152 @@
+package crypto
+
+import (
+	"crypto"
+	"errors"
+	"testing"
+)
+
+type fakeSigner struct{}
+
+func (s *fakeSigner) Sign(dataToBeSigned []byte) ([]byte, error) { return dataToBeSigned, nil }
+func (s *fakeSigner) Algorithm() string                          { return "FAKE" }
+func (s *fakeSigner) Public() crypto.PublicKey                   { return nil }
+func (s *fakeSigner) KeyParameters() KeyParameters               { return KeyParameters{} }
+func (s *fakeSigner) Scheme() SignatureScheme                    { return SignatureScheme{} }
+
+type fakeMarshaler struct{}
+
+func (m fakeMarshaler) Marshal(signer Signer) ([]byte, []byte, error) { return nil, nil, nil }
+func (m fakeMarshaler) Unmarshal(privateKeyBytes []byte, scheme SignatureScheme) (Signer, error) {
+	return &fakeSigner{}, nil
+}
+
+func fakeAlgorithm() Algorithm {
+	return Algorithm{
+		Name: "FAKE",
+		Parameters: func(requested KeyParameters) (KeyParameters, error) {
+			return requested, nil
+		},
+		Scheme: func(requested SignatureScheme) (SignatureScheme, error) {
+			return requested, nil
+		},
+		Generate: func(parameters KeyParameters, scheme SignatureScheme) (Signer, error) {
+			return &fakeSigner{}, nil
+		},
+		Verifier: func(publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error) {
+			return nil, ErrUnsupportedKey
+		},
+		Marshaler: fakeMarshaler{},
+		JWK: func(publicKey crypto.PublicKey) (*JWK, error) {
+			return nil, ErrUnsupportedKey
+		},
+	}
+}
+
+func TestAlgorithmRegistry(t *testing.T) {
+	t.Run("Built-in algorithms", func(t *testing.T) {
+		for _, name := range []string{ALGORITHM_RSA, ALGORITHM_ECC} {
+			if _, err := GetAlgorithm(name); err != nil {
+				t.Error("Expected", name, "to be registered, got:", err)
+			}
+		}
+	})
+
+	t.Run("Register algorithm", func(t *testing.T) {
+		if err := RegisterAlgorithm(fakeAlgorithm()); err != nil {
+			t.Fatal("Error while registering algorithm, got:", err)
+		}
+		t.Cleanup(func() {
+			algorithmsMu.Lock()
+			delete(algorithms, "FAKE")
+			algorithmsMu.Unlock()
+		})
+
+		signer, err := CreateSigner("FAKE")
+		if err != nil {
+			t.Fatal("Error while creating signer, got:", err)
+		}
+		if signer.Algorithm() != "FAKE" {
+			t.Error("Expected FAKE signer, got:", signer.Algorithm())
+		}
+	})
+
+	t.Run("Duplicate algorithm", func(t *testing.T) {
+		algorithm := fakeAlgorithm()
+		algorithm.Name = ALGORITHM_ECC
+		if err := RegisterAlgorithm(algorithm); !errors.Is(err, ErrAlgorithmExists) {
+			t.Error("Expected ErrAlgorithmExists, got:", err)
+		}
+		if signer, _ := CreateSigner(ALGORITHM_ECC); signer.Algorithm() != ALGORITHM_ECC {
+			t.Error("Expected the ECC registration to be kept")
+		}
+	})
+
+	t.Run("Incomplete algorithm", func(t *testing.T) {
+		incomplete := []func(algorithm *Algorithm){
+			func(algorithm *Algorithm) { algorithm.Name = "" },
+			func(algorithm *Algorithm) { algorithm.Generate = nil },
+			func(algorithm *Algorithm) { algorithm.Verifier = nil },
+			func(algorithm *Algorithm) { algorithm.Marshaler = nil },
+			func(algorithm *Algorithm) { algorithm.JWK = nil },
+		}
+		for i, remove := range incomplete {
+			algorithm := fakeAlgorithm()
+			remove(&algorithm)
+			if err := RegisterAlgorithm(algorithm); !errors.Is(err, ErrInvalidAlgorithm) {
+				t.Errorf("Case %d: expected ErrInvalidAlgorithm, got: %v", i, err)
+			}
+		}
+		if _, err := GetAlgorithm("FAKE"); err != ErrUnknownAlgorithm {
+			t.Error("Expected incomplete algorithms not to be registered, got:", err)
+		}
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		_, err := CreateSigner("DSA")
+		if err != ErrUnknownAlgorithm {
+			t.Error("Expected ErrUnknownAlgorithm, got:", err)
+		}
+	})
+}
+
+func TestKeyPolicy(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		requested KeyParameters
+		expected  KeyParameters
+		valid     bool
+	}{
+		{ALGORITHM_RSA, KeyParameters{}, KeyParameters{Size: 2048}, true},
+		{ALGORITHM_RSA, KeyParameters{Size: 3072}, KeyParameters{Size: 3072}, true},
+		{ALGORITHM_RSA, KeyParameters{Size: 4096}, KeyParameters{Size: 4096}, true},
+		{ALGORITHM_RSA, KeyParameters{Size: 512}, KeyParameters{}, false},
+		{ALGORITHM_RSA, KeyParameters{Curve: "P-256"}, KeyParameters{}, false},
+		{ALGORITHM_ECC, KeyParameters{}, KeyParameters{Curve: "P-384"}, true},
+		{ALGORITHM_ECC, KeyParameters{Curve: "P-256"}, KeyParameters{Curve: "P-256"}, true},
+		{ALGORITHM_ECC, KeyParameters{Curve: "P-521"}, KeyParameters{Curve: "P-521"}, true},
+		{ALGORITHM_ECC, KeyParameters{Curve: "P-224"}, KeyParameters{}, false},
+		{ALGORITHM_ED25519, KeyParameters{}, KeyParameters{}, true},
+		{ALGORITHM_ED25519, KeyParameters{Size: 256}, KeyParameters{}, false},
+	}
+	for _, test := range tests {
+		algorithm, _ := GetAlgorithm(test.algorithm)
+		parameters, err := algorithm.Parameters(test.requested)
+		if test.valid && (err != nil || parameters != test.expected) {
+			t.Errorf("%s %+v: expected %+v, got %+v (%v)", test.algorithm, test.requested, test.expected, parameters, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidKeyParameters) {
+			t.Errorf("%s %+v: expected ErrInvalidKeyParameters, got %v", test.algorithm, test.requested, err)
+		}
+	}
+}
+
+func TestCreateSignerWithParameters(t *testing.T) {
+	signer, err := CreateSignerWithParameters(ALGORITHM_ECC, KeyParameters{Curve: "P-256"}, SignatureScheme{})
+	if err != nil {
+		t.Fatal("Error while creating signer, got:", err)
+	}
+	if signer.KeyParameters().Curve != "P-256" {
+		t.Error("Expected curve P-256, got:", signer.KeyParameters().Curve)
+	}
+}
@@ -1,17 +1,117 @@
 package crypto
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
+	"fmt"
 )
 
+// ALGORITHM_ECC is a constant for the ECC algorithm.
+const ALGORITHM_ECC = "ECC"
+
+// DefaultECCCurve is the curve used if no curve is requested.
+const DefaultECCCurve = "P-384"
+
+// DefaultECCScheme is the signature scheme used if no scheme is requested.
+var DefaultECCScheme = SignatureScheme{Hash: HASH_SHA256}
+
+// ECCCurves are the curves allowed by the key policy.
+var ECCCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func init() {
+	mustRegisterAlgorithm(Algorithm{
+		Name: ALGORITHM_ECC,
+		Parameters: func(requested KeyParameters) (KeyParameters, error) {
+			if requested.Size != 0 {
+				return KeyParameters{}, fmt.Errorf("%w: ECC keys do not use a key size", ErrInvalidKeyParameters)
+			}
+			if requested.Curve == "" {
+				return KeyParameters{Curve: DefaultECCCurve}, nil
+			}
+			if _, ok := ECCCurves[requested.Curve]; !ok {
+				return KeyParameters{}, fmt.Errorf("%w: ECC curve must be one of P-256, P-384, P-521", ErrInvalidKeyParameters)
+			}
+			return requested, nil
+		},
+		Scheme: func(requested SignatureScheme) (SignatureScheme, error) {
+			if requested.Padding != "" {
+				return SignatureScheme{}, fmt.Errorf("%w: ECDSA signatures do not use a padding", ErrInvalidSignatureScheme)
+			}
+			return validateHash(requested)
+		},
+		Generate: func(parameters KeyParameters, scheme SignatureScheme) (Signer, error) {
+			keyGenerator := ECCGenerator{Curve: ECCCurves[parameters.Curve]}
+			keyPair, err := keyGenerator.Generate()
+			if err != nil {
+				return nil, err
+			}
+			return NewECDSASignerWithScheme(*keyPair, scheme), nil
+		},
+		Verifier: func(publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error) {
+			ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+			if !ok {
+				return nil, ErrUnsupportedKey
+			}
+			return NewECDSAVerifierWithScheme(ecdsaPublicKey, scheme), nil
+		},
+		Marshaler: NewECCMarshaler(),
+		JWK:       eccPublicKeyJWK,
+	})
+}
+
+// eccPublicKeyJWK converts an ECC public key into its JWK representation.
+func eccPublicKeyJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	size := (ecdsaPublicKey.Curve.Params().BitSize + 7) / 8
+	return &JWK{
+		KeyType: "EC",
+		Curve:   ecdsaPublicKey.Curve.Params().Name,
+		X:       base64.RawURLEncoding.EncodeToString(ecdsaPublicKey.X.FillBytes(make([]byte, size))),
+		Y:       base64.RawURLEncoding.EncodeToString(ecdsaPublicKey.Y.FillBytes(make([]byte, size))),
+	}, nil
+}
+
 // ECCKeyPair is a DTO that holds ECC private and public keys.
 type ECCKeyPair struct {
 	Public  *ecdsa.PublicKey
 	Private *ecdsa.PrivateKey
 }
 
+// ECCGenerator generates an ECC key pair.
+type ECCGenerator struct {
+	// Curve is the elliptic curve of the key, P-384 if not set.
+	Curve elliptic.Curve
+}
+
+// Generate generates a new ECCKeyPair.
+func (g *ECCGenerator) Generate() (*ECCKeyPair, error) {
+	curve := g.Curve
+	if curve == nil {
+		curve = ECCCurves[DefaultECCCurve]
+	}
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ECCKeyPair{
+		Public:  &key.PublicKey,
+		Private: key,
+	}, nil
+}
+
 // ECCMarshaler can encode and decode an ECC key pair.
 type ECCMarshaler struct{}
 
@@ -49,6 +149,9 @@ func (m ECCMarshaler) Encode(keyPair ECCKeyPair) ([]byte, []byte, error) {
 // Decode assembles an ECCKeyPair from an encoded private key.
 func (m ECCMarshaler) Decode(privateKeyBytes []byte) (*ECCKeyPair, error) {
 	block, _ := pem.Decode(privateKeyBytes)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
 	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
 	if err != nil {
 		return nil, err
@@ -59,3 +162,138 @@ func (m ECCMarshaler) Decode(privateKeyBytes []byte) (*ECCKeyPair, error) {
 		Public:  &privateKey.PublicKey,
 	}, nil
 }
+
+// Marshal encodes the key pair of an ECDSASigner.
+func (m ECCMarshaler) Marshal(signer Signer) ([]byte, []byte, error) {
+	ecdsaSigner, ok := signer.(*ECDSASigner)
+	if !ok {
+		return nil, nil, ErrUnsupportedKey
+	}
+	return m.Encode(ecdsaSigner.KeyPair)
+}
+
+// Unmarshal creates an ECDSASigner from an encoded private key.
+func (m ECCMarshaler) Unmarshal(privateKeyBytes []byte, scheme SignatureScheme) (Signer, error) {
+	keyPair, err := m.Decode(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewECDSASignerWithScheme(*keyPair, scheme), nil
+}
+
+// ECDSASigner is a concrete implementation of the Signer interface for ECC keys.
+type ECDSASigner struct {
+	KeyPair         ECCKeyPair
+	SignatureScheme SignatureScheme
+}
+
+// NewECDSASigner is a factory to instantiate a new ECDSASigner using SHA-256.
+func NewECDSASigner(keyPair ECCKeyPair) *ECDSASigner {
+	return NewECDSASignerWithScheme(keyPair, DefaultECCScheme)
+}
+
+// NewECDSASignerWithScheme is a factory to instantiate a new ECDSASigner using the given signature scheme.
+func NewECDSASignerWithScheme(keyPair ECCKeyPair, scheme SignatureScheme) *ECDSASigner {
+	return &ECDSASigner{
+		KeyPair:         keyPair,
+		SignatureScheme: scheme,
+	}
+}
+
+// Sign signs the given data with the ECC private key.
+func (s *ECDSASigner) Sign(dataToBeSigned []byte) ([]byte, error) {
+	hash, hashed, err := s.SignatureScheme.Digest(dataToBeSigned)
+	if err != nil {
+		return nil, err
+	}
+	if s.SignatureScheme.Deterministic {
+		// without a random source, the standard library derives the nonce from the
+		// key and the hash as described in RFC 6979
+		return s.KeyPair.Private.Sign(nil, hashed, hash)
+	}
+	signature, err := ecdsa.SignASN1(rand.Reader, s.KeyPair.Private, hashed)
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// Algorithm returns the name of the algorithm used by the signer.
+func (s *ECDSASigner) Algorithm() string {
+	return ALGORITHM_ECC
+}
+
+// Public returns the ECC public key.
+func (s *ECDSASigner) Public() crypto.PublicKey {
+	return s.KeyPair.Public
+}
+
+// KeyParameters returns the curve of the ECC key.
+func (s *ECDSASigner) KeyParameters() KeyParameters {
+	return KeyParameters{Curve: s.KeyPair.Public.Curve.Params().Name}
+}
+
+// Scheme returns the hash and nonce generation used by the signer.
+func (s *ECDSASigner) Scheme() SignatureScheme {
+	return s.SignatureScheme
+}
+
+// JWSAlgorithm returns ES256, ES384 or ES512 depending on the curve of the key.
+func (s *ECDSASigner) JWSAlgorithm() (string, error) {
+	switch s.KeyPair.Public.Curve.Params().Name {
+	case "P-256":
+		return "ES256", nil
+	case "P-384":
+		return "ES384", nil
+	case "P-521":
+		return "ES512", nil
+	default:
+		return "", ErrUnsupportedKey
+	}
+}
+
+// SignJWS signs the JWS signing input with the hash JWS prescribes for the curve
+// of the key and returns the signature as raw r||s.
+func (s *ECDSASigner) SignJWS(signingInput []byte) ([]byte, error) {
+	hashes := map[string]string{"P-256": HASH_SHA256, "P-384": HASH_SHA384, "P-521": HASH_SHA512}
+	signer := NewECDSASignerWithScheme(s.KeyPair, SignatureScheme{
+		Hash:          hashes[s.KeyPair.Public.Curve.Params().Name],
+		Deterministic: s.SignatureScheme.Deterministic,
+	})
+	signature, err := signer.Sign(signingInput)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeSignatureRaw(s.KeyPair.Public, signature)
+}
+
+// ECDSAVerifier is a concrete implementation of the Verifier interface for ECC keys.
+type ECDSAVerifier struct {
+	PublicKey       *ecdsa.PublicKey
+	SignatureScheme SignatureScheme
+}
+
+// NewECDSAVerifier is a factory to instantiate a new ECDSAVerifier for SHA-256.
+func NewECDSAVerifier(publicKey *ecdsa.PublicKey) *ECDSAVerifier {
+	return NewECDSAVerifierWithScheme(publicKey, DefaultECCScheme)
+}
+
+// NewECDSAVerifierWithScheme is a factory to instantiate a new ECDSAVerifier for the given signature scheme.
+func NewECDSAVerifierWithScheme(publicKey *ecdsa.PublicKey, scheme SignatureScheme) *ECDSAVerifier {
+	return &ECDSAVerifier{
+		PublicKey:       publicKey,
+		SignatureScheme: scheme,
+	}
+}
+
+// Verify checks an ASN.1 encoded ECDSA signature over the hash of the signed data.
+func (v *ECDSAVerifier) Verify(signedData []byte, signature []byte) error {
+	_, hashed, err := v.SignatureScheme.Digest(signedData)
+	if err != nil {
+		return err
+	}
+	if !ecdsa.VerifyASN1(v.PublicKey, hashed, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
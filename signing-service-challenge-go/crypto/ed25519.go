@@ -0,0 +1,225 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// ALGORITHM_ED25519 is a constant for the Ed25519 algorithm.
+const ALGORITHM_ED25519 = "ED25519"
+
+func init() {
+	mustRegisterAlgorithm(Algorithm{
+		Name: ALGORITHM_ED25519,
+		Parameters: func(requested KeyParameters) (KeyParameters, error) {
+			if requested != (KeyParameters{}) {
+				return KeyParameters{}, fmt.Errorf("%w: Ed25519 keys have no parameters", ErrInvalidKeyParameters)
+			}
+			return requested, nil
+		},
+		Scheme: func(requested SignatureScheme) (SignatureScheme, error) {
+			if requested != (SignatureScheme{}) {
+				return SignatureScheme{}, fmt.Errorf("%w: Ed25519 has a fixed signature scheme", ErrInvalidSignatureScheme)
+			}
+			return requested, nil
+		},
+		Generate: func(parameters KeyParameters, scheme SignatureScheme) (Signer, error) {
+			keyGenerator := Ed25519Generator{}
+			keyPair, err := keyGenerator.Generate()
+			if err != nil {
+				return nil, err
+			}
+			return NewEd25519Signer(*keyPair), nil
+		},
+		Verifier: func(publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error) {
+			ed25519PublicKey, ok := publicKey.(ed25519.PublicKey)
+			if !ok {
+				return nil, ErrUnsupportedKey
+			}
+			return NewEd25519Verifier(ed25519PublicKey), nil
+		},
+		Marshaler: NewEd25519Marshaler(),
+		JWK:       ed25519PublicKeyJWK,
+	})
+}
+
+// ed25519PublicKeyJWK converts an Ed25519 public key into its JWK representation.
+func ed25519PublicKeyJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	ed25519PublicKey, ok := publicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return &JWK{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(ed25519PublicKey),
+	}, nil
+}
+
+// Ed25519KeyPair is a DTO that holds Ed25519 private and public keys.
+type Ed25519KeyPair struct {
+	Public  ed25519.PublicKey
+	Private ed25519.PrivateKey
+}
+
+// Ed25519Generator generates an Ed25519 key pair.
+type Ed25519Generator struct{}
+
+// Generate generates a new Ed25519KeyPair.
+func (g *Ed25519Generator) Generate() (*Ed25519KeyPair, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ed25519KeyPair{
+		Public:  public,
+		Private: private,
+	}, nil
+}
+
+// Ed25519Marshaler can encode and decode an Ed25519 key pair.
+type Ed25519Marshaler struct{}
+
+// NewEd25519Marshaler creates a new Ed25519Marshaler.
+func NewEd25519Marshaler() Ed25519Marshaler {
+	return Ed25519Marshaler{}
+}
+
+// Encode takes an Ed25519KeyPair and encodes it to be written on disk.
+// The private key is stored as PKCS#8, the public key as PKIX structure.
+func (m Ed25519Marshaler) Encode(keyPair Ed25519KeyPair) ([]byte, []byte, error) {
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(keyPair.Private)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(keyPair.Public)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encodedPrivate := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privateKeyBytes,
+	})
+
+	encodedPublic := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	})
+
+	return encodedPublic, encodedPrivate, nil
+}
+
+// Decode assembles an Ed25519KeyPair from a PKCS#8 encoded private key.
+func (m Ed25519Marshaler) Decode(privateKeyBytes []byte) (*Ed25519KeyPair, error) {
+	block, _ := pem.Decode(privateKeyBytes)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return &Ed25519KeyPair{
+		Private: privateKey,
+		Public:  privateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// Marshal encodes the key pair of an Ed25519Signer.
+func (m Ed25519Marshaler) Marshal(signer Signer) ([]byte, []byte, error) {
+	ed25519Signer, ok := signer.(*Ed25519Signer)
+	if !ok {
+		return nil, nil, ErrUnsupportedKey
+	}
+	return m.Encode(ed25519Signer.KeyPair)
+}
+
+// Unmarshal creates an Ed25519Signer from an encoded private key.
+func (m Ed25519Marshaler) Unmarshal(privateKeyBytes []byte, scheme SignatureScheme) (Signer, error) {
+	keyPair, err := m.Decode(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewEd25519Signer(*keyPair), nil
+}
+
+// Ed25519Signer is a concrete implementation of the Signer interface for Ed25519 keys.
+type Ed25519Signer struct {
+	KeyPair Ed25519KeyPair
+}
+
+// NewEd25519Signer is a factory to instantiate a new Ed25519Signer.
+func NewEd25519Signer(keyPair Ed25519KeyPair) *Ed25519Signer {
+	return &Ed25519Signer{
+		KeyPair: keyPair,
+	}
+}
+
+// Sign signs the given data with the Ed25519 private key.
+// Ed25519 hashes the data itself and produces deterministic signatures.
+func (s *Ed25519Signer) Sign(dataToBeSigned []byte) ([]byte, error) {
+	return ed25519.Sign(s.KeyPair.Private, dataToBeSigned), nil
+}
+
+// Algorithm returns the name of the algorithm used by the signer.
+func (s *Ed25519Signer) Algorithm() string {
+	return ALGORITHM_ED25519
+}
+
+// Public returns the Ed25519 public key.
+func (s *Ed25519Signer) Public() crypto.PublicKey {
+	return s.KeyPair.Public
+}
+
+// KeyParameters returns no parameters, Ed25519 keys have a fixed size.
+func (s *Ed25519Signer) KeyParameters() KeyParameters {
+	return KeyParameters{}
+}
+
+// Scheme returns no scheme, Ed25519 always hashes the data with SHA-512 itself.
+func (s *Ed25519Signer) Scheme() SignatureScheme {
+	return SignatureScheme{}
+}
+
+// JWSAlgorithm returns EdDSA.
+func (s *Ed25519Signer) JWSAlgorithm() (string, error) {
+	return "EdDSA", nil
+}
+
+// SignJWS signs the JWS signing input with the Ed25519 private key.
+func (s *Ed25519Signer) SignJWS(signingInput []byte) ([]byte, error) {
+	return s.Sign(signingInput)
+}
+
+// Ed25519Verifier is a concrete implementation of the Verifier interface for Ed25519 keys.
+type Ed25519Verifier struct {
+	PublicKey ed25519.PublicKey
+}
+
+// NewEd25519Verifier is a factory to instantiate a new Ed25519Verifier.
+func NewEd25519Verifier(publicKey ed25519.PublicKey) *Ed25519Verifier {
+	return &Ed25519Verifier{
+		PublicKey: publicKey,
+	}
+}
+
+// Verify checks an Ed25519 signature over the signed data.
+func (v *Ed25519Verifier) Verify(signedData []byte, signature []byte) error {
+	if !ed25519.Verify(v.PublicKey, signedData, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestEd25519Signer_Sign(t *testing.T) {
+	ed25519Generator := &Ed25519Generator{}
+	ed25519KeyPair, _ := ed25519Generator.Generate()
+	message := []byte("test_data")
+
+	ed25519Signer := NewEd25519Signer(*ed25519KeyPair)
+	signature, err := ed25519Signer.Sign(message)
+	if err != nil {
+		t.Error("Error while signing, got:", err)
+	}
+
+	if !ed25519.Verify(ed25519KeyPair.Public, message, signature) {
+		t.Error("Signature verification failed")
+	}
+
+	again, _ := ed25519Signer.Sign(message)
+	if !bytes.Equal(signature, again) {
+		t.Error("Expected Ed25519 signatures to be deterministic")
+	}
+}
+
+func TestEd25519Algorithm(t *testing.T) {
+	signer, err := CreateSigner(ALGORITHM_ED25519)
+	if err != nil {
+		t.Fatal("Error while creating signer, got:", err)
+	}
+	marshaler, _ := GetKeyMarshaler(ALGORITHM_ED25519)
+	_, privateKey, err := marshaler.Marshal(signer)
+	if err != nil {
+		t.Fatal("Error while marshalling, got:", err)
+	}
+	restored, err := marshaler.Unmarshal(privateKey, signer.Scheme())
+	if err != nil {
+		t.Fatal("Error while unmarshalling, got:", err)
+	}
+
+	verifier, _ := CreateVerifier(ALGORITHM_ED25519, signer.Public(), signer.Scheme())
+	signature, _ := restored.Sign([]byte("test_data"))
+	if err := verifier.Verify([]byte("test_data"), signature); err != nil {
+		t.Error("Error while verifying, got:", err)
+	}
+
+	jwk, err := MarshalPublicKeyJWK(ALGORITHM_ED25519, signer.Public())
+	if err != nil || jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" {
+		t.Error("Expected an OKP JWK, got:", jwk, err)
+	}
+}
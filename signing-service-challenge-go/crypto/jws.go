@@ -0,0 +1,91 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// JWSSigner is implemented by signers that can create JSON Web Signatures (RFC 7515).
+type JWSSigner interface {
+	// JWSAlgorithm returns the JWS "alg" header value matching the key of the signer.
+	JWSAlgorithm() (string, error)
+	// SignJWS signs the JWS signing input and returns the signature in its JWS representation.
+	SignJWS(signingInput []byte) ([]byte, error)
+}
+
+type jwsHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid,omitempty"`
+}
+
+// CreateJWS signs the payload with the signer and returns it in JWS compact serialization.
+func CreateJWS(signer Signer, keyId string, payload []byte) (string, error) {
+	jwsSigner, ok := signer.(JWSSigner)
+	if !ok {
+		return "", ErrUnsupportedKey
+	}
+
+	alg, err := jwsSigner.JWSAlgorithm()
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(jwsHeader{Algorithm: alg, KeyId: keyId})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := jwsSigner.SignJWS([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// EncodeSignatureRaw converts a signature into its raw form. ECDSA signatures are
+// converted from ASN.1 DER to the IEEE P1363 concatenation r||s, signatures of
+// other algorithms are already raw and returned unchanged.
+func EncodeSignatureRaw(publicKey crypto.PublicKey, signature []byte) ([]byte, error) {
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return signature, nil
+	}
+
+	var rs struct{ R, S *big.Int }
+	rest, err := asn1.Unmarshal(signature, &rs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	size := (ecdsaPublicKey.Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	rs.R.FillBytes(raw[:size])
+	rs.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// DecodeSignatureRaw converts a raw signature back into the form verifiers
+// expect. ECDSA signatures are converted from r||s to ASN.1 DER, signatures of
+// other algorithms are returned unchanged.
+func DecodeSignatureRaw(publicKey crypto.PublicKey, raw []byte) ([]byte, error) {
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return raw, nil
+	}
+
+	size := (ecdsaPublicKey.Curve.Params().BitSize + 7) / 8
+	if len(raw) != 2*size {
+		return nil, ErrInvalidSignature
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(raw[:size]),
+		new(big.Int).SetBytes(raw[size:]),
+	})
+}
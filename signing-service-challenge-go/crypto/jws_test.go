@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCreateJWS(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		parameters KeyParameters
+		scheme     SignatureScheme
+		alg        string
+	}{
+		{ALGORITHM_RSA, KeyParameters{}, SignatureScheme{}, "PS256"},
+		{ALGORITHM_RSA, KeyParameters{}, SignatureScheme{Hash: HASH_SHA512, Padding: PADDING_PKCS1V15}, "RS512"},
+		{ALGORITHM_ECC, KeyParameters{Curve: "P-256"}, SignatureScheme{}, "ES256"},
+		{ALGORITHM_ECC, KeyParameters{}, SignatureScheme{}, "ES384"},
+		{ALGORITHM_ED25519, KeyParameters{}, SignatureScheme{}, "EdDSA"},
+	}
+	for _, test := range tests {
+		t.Run(test.alg, func(t *testing.T) {
+			signer, err := CreateSignerWithParameters(test.algorithm, test.parameters, test.scheme)
+			if err != nil {
+				t.Fatal("Error while creating signer, got:", err)
+			}
+			jws, err := CreateJWS(signer, "device", []byte("0_data_ZGV2aWNl"))
+			if err != nil {
+				t.Fatal("Error while creating JWS, got:", err)
+			}
+
+			parts := strings.Split(jws, ".")
+			if len(parts) != 3 {
+				t.Fatal("Expected compact JWS with 3 parts, got:", jws)
+			}
+			headerBytes, _ := base64.RawURLEncoding.DecodeString(parts[0])
+			var header jwsHeader
+			json.Unmarshal(headerBytes, &header)
+			if header.Algorithm != test.alg || header.KeyId != "device" {
+				t.Errorf("Expected header alg %s kid device, got %+v", test.alg, header)
+			}
+			payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if string(payload) != "0_data_ZGV2aWNl" {
+				t.Error("Expected payload to be the signed data, got:", string(payload))
+			}
+
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			if !verifyJWS(test.alg, signer.Public(), []byte(parts[0]+"."+parts[1]), signature) {
+				t.Error("JWS signature verification failed")
+			}
+		})
+	}
+}
+
+func TestEncodeSignatureRaw(t *testing.T) {
+	signer, _ := CreateSignerWithParameters(ALGORITHM_ECC, KeyParameters{Curve: "P-521"}, SignatureScheme{})
+	message := []byte("test_data")
+	signature, _ := signer.Sign(message)
+
+	raw, err := EncodeSignatureRaw(signer.Public(), signature)
+	if err != nil {
+		t.Fatal("Error while encoding signature, got:", err)
+	}
+	if len(raw) != 132 {
+		t.Error("Expected raw P-521 signature of 132 bytes, got", len(raw))
+	}
+	hashed := sha256.Sum256(message)
+	r, s := new(big.Int).SetBytes(raw[:66]), new(big.Int).SetBytes(raw[66:])
+	if !ecdsa.Verify(signer.Public().(*ecdsa.PublicKey), hashed[:], r, s) {
+		t.Error("Raw signature verification failed")
+	}
+
+	decoded, err := DecodeSignatureRaw(signer.Public(), raw)
+	if err != nil || !bytes.Equal(decoded, signature) {
+		t.Error("Expected raw signature to decode to the DER signature, got error:", err)
+	}
+}
+
+func verifyJWS(alg string, publicKey crypto.PublicKey, signingInput []byte, signature []byte) bool {
+	switch alg {
+	case "PS256":
+		hashed := sha256.Sum256(signingInput)
+		options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		return rsa.VerifyPSS(publicKey.(*rsa.PublicKey), crypto.SHA256, hashed[:], signature, options) == nil
+	case "RS512":
+		hashed := sha512.Sum512(signingInput)
+		return rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), crypto.SHA512, hashed[:], signature) == nil
+	case "ES256", "ES384":
+		hash := crypto.SHA256
+		if alg == "ES384" {
+			hash = crypto.SHA384
+		}
+		h := hash.New()
+		h.Write(signingInput)
+		size := len(signature) / 2
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(publicKey.(*ecdsa.PublicKey), h.Sum(nil), r, s)
+	case "EdDSA":
+		return ed25519.Verify(publicKey.(ed25519.PublicKey), signingInput, signature)
+	}
+	return false
+}
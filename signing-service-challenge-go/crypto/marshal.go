@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"errors"
+)
+
+// ErrInvalidPEM is an error for key material that is not PEM encoded.
+var ErrInvalidPEM = errors.New("invalid PEM encoded key")
+
+// KeyMarshaler defines a contract for encoding the key pair of a signer to be
+// written on disk and for restoring the signer from the encoded private key.
+type KeyMarshaler interface {
+	// Marshal returns the encoded public and private key of the signer.
+	Marshal(signer Signer) ([]byte, []byte, error)
+	// Unmarshal creates a signer using the given signature scheme from an encoded private key.
+	Unmarshal(privateKeyBytes []byte, scheme SignatureScheme) (Signer, error)
+}
+
+// GetKeyMarshaler returns the KeyMarshaler registered for the given algorithm.
+func GetKeyMarshaler(algorithm string) (KeyMarshaler, error) {
+	a, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return a.Marshaler, nil
+}
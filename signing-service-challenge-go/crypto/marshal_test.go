@@ -0,0 +1,59 @@
+package crypto
+
+import (
+	"testing"
+)
+
+func TestKeyMarshaler(t *testing.T) {
+	for _, algorithm := range []string{ALGORITHM_RSA, ALGORITHM_ECC} {
+		t.Run(algorithm, func(t *testing.T) {
+			marshaler, err := GetKeyMarshaler(algorithm)
+			if err != nil {
+				t.Fatal("Error while getting key marshaler, got:", err)
+			}
+			signer, _ := CreateSigner(algorithm)
+
+			_, privateKey, err := marshaler.Marshal(signer)
+			if err != nil {
+				t.Fatal("Error while marshalling, got:", err)
+			}
+			restored, err := marshaler.Unmarshal(privateKey, signer.Scheme())
+			if err != nil {
+				t.Fatal("Error while unmarshalling, got:", err)
+			}
+			if restored.Algorithm() != algorithm {
+				t.Error("Expected algorithm", algorithm, "but got", restored.Algorithm())
+			}
+
+			verifier, _ := CreateVerifier(algorithm, signer.Public(), signer.Scheme())
+			signature, _ := restored.Sign([]byte("test_data"))
+			if err := verifier.Verify([]byte("test_data"), signature); err != nil {
+				t.Error("Expected restored signer to use the original key, got:", err)
+			}
+		})
+	}
+
+	t.Run("Invalid PEM", func(t *testing.T) {
+		for _, marshaler := range []KeyMarshaler{NewRSAMarshaler(), NewECCMarshaler()} {
+			_, err := marshaler.Unmarshal([]byte("not a PEM block"), SignatureScheme{})
+			if err != ErrInvalidPEM {
+				t.Error("Expected ErrInvalidPEM, got:", err)
+			}
+		}
+	})
+
+	t.Run("Wrong signer", func(t *testing.T) {
+		signer, _ := CreateSigner(ALGORITHM_ECC)
+		_, _, err := NewRSAMarshaler().Marshal(signer)
+		if err != ErrUnsupportedKey {
+			t.Error("Expected ErrUnsupportedKey, got:", err)
+		}
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		_, err := GetKeyMarshaler("DSA")
+		if err != ErrUnknownAlgorithm {
+			t.Error("Expected ErrUnknownAlgorithm, got:", err)
+		}
+	})
+}
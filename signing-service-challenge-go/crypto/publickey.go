@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// ErrUnsupportedKey is an error for public keys that cannot be encoded.
+var ErrUnsupportedKey = errors.New("unsupported key type")
+
+// JWK is the JSON Web Key (RFC 7517) representation of a public key.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyId   string `json:"kid,omitempty"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+}
+
+// MarshalPublicKey encodes a public key as PEM encoded PKIX structure.
+func MarshalPublicKey(publicKey crypto.PublicKey) ([]byte, error) {
+	publicKeyBytes, err := MarshalPublicKeyDER(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	}), nil
+}
+
+// MarshalPublicKeyDER encodes a public key as DER encoded PKIX structure.
+func MarshalPublicKeyDER(publicKey crypto.PublicKey) ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
+
+// MarshalPublicKeyJWK converts a public key of an algorithm into its JWK representation.
+func MarshalPublicKeyJWK(algorithmName string, publicKey crypto.PublicKey) (*JWK, error) {
+	algorithm, err := GetAlgorithm(algorithmName)
+	if err != nil {
+		return nil, err
+	}
+	return algorithm.JWK(publicKey)
+}
@@ -1,17 +1,120 @@
 package crypto
 
 import (
+	"crypto"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
+	"fmt"
+	"math/big"
 )
 
+// ALGORITHM_RSA is a constant for the RSA algorithm.
+const ALGORITHM_RSA = "RSA"
+
+// DefaultRSAKeySize is the RSA modulus size used if no size is requested.
+const DefaultRSAKeySize = 2048
+
+// RSAKeySizes are the RSA modulus sizes allowed by the key policy.
+var RSAKeySizes = []int{2048, 3072, 4096}
+
+// DefaultRSAScheme is the signature scheme used if no scheme is requested.
+var DefaultRSAScheme = SignatureScheme{Hash: HASH_SHA256, Padding: PADDING_PSS}
+
+func init() {
+	mustRegisterAlgorithm(Algorithm{
+		Name: ALGORITHM_RSA,
+		Parameters: func(requested KeyParameters) (KeyParameters, error) {
+			if requested.Curve != "" {
+				return KeyParameters{}, fmt.Errorf("%w: RSA keys do not use a curve", ErrInvalidKeyParameters)
+			}
+			if requested.Size == 0 {
+				return KeyParameters{Size: DefaultRSAKeySize}, nil
+			}
+			for _, size := range RSAKeySizes {
+				if requested.Size == size {
+					return requested, nil
+				}
+			}
+			return KeyParameters{}, fmt.Errorf("%w: RSA key size must be one of %v", ErrInvalidKeyParameters, RSAKeySizes)
+		},
+		Scheme: func(requested SignatureScheme) (SignatureScheme, error) {
+			if requested.Deterministic {
+				return SignatureScheme{}, fmt.Errorf("%w: deterministic signing is only supported for ECDSA", ErrInvalidSignatureScheme)
+			}
+			switch requested.Padding {
+			case "":
+				requested.Padding = DefaultRSAScheme.Padding
+			case PADDING_PSS, PADDING_PKCS1V15:
+			default:
+				return SignatureScheme{}, fmt.Errorf("%w: RSA padding must be PSS or PKCS1v15", ErrInvalidSignatureScheme)
+			}
+			return validateHash(requested)
+		},
+		Generate: func(parameters KeyParameters, scheme SignatureScheme) (Signer, error) {
+			keyGenerator := RSAGenerator{Bits: parameters.Size}
+			keyPair, err := keyGenerator.Generate()
+			if err != nil {
+				return nil, err
+			}
+			return NewRSASignerWithScheme(*keyPair, scheme), nil
+		},
+		Verifier: func(publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error) {
+			rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+			if !ok {
+				return nil, ErrUnsupportedKey
+			}
+			return NewRSAVerifierWithScheme(rsaPublicKey, scheme), nil
+		},
+		Marshaler: NewRSAMarshaler(),
+		JWK:       rsaPublicKeyJWK,
+	})
+}
+
+// rsaPublicKeyJWK converts an RSA public key into its JWK representation.
+func rsaPublicKeyJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return &JWK{
+		KeyType: "RSA",
+		N:       base64.RawURLEncoding.EncodeToString(rsaPublicKey.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaPublicKey.E)).Bytes()),
+	}, nil
+}
+
 // RSAKeyPair is a DTO that holds RSA private and public keys.
 type RSAKeyPair struct {
 	Public  *rsa.PublicKey
 	Private *rsa.PrivateKey
 }
 
+// RSAGenerator generates a RSA key pair.
+type RSAGenerator struct {
+	// Bits is the modulus size, DefaultRSAKeySize if not set.
+	Bits int
+}
+
+// Generate generates a new RSAKeyPair.
+func (g *RSAGenerator) Generate() (*RSAKeyPair, error) {
+	bits := g.Bits
+	if bits == 0 {
+		bits = DefaultRSAKeySize
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RSAKeyPair{
+		Public:  &key.PublicKey,
+		Private: key,
+	}, nil
+}
+
 // RSAMarshaler can encode and decode an RSA key pair.
 type RSAMarshaler struct{}
 
@@ -20,9 +123,9 @@ func NewRSAMarshaler() RSAMarshaler {
 	return RSAMarshaler{}
 }
 
-// Marshal takes an RSAKeyPair and encodes it to be written on disk.
+// Encode takes an RSAKeyPair and encodes it to be written on disk.
 // It returns the public and the private key as a byte slice.
-func (m *RSAMarshaler) Marshal(keyPair RSAKeyPair) ([]byte, []byte, error) {
+func (m RSAMarshaler) Encode(keyPair RSAKeyPair) ([]byte, []byte, error) {
 	privateKeyBytes := x509.MarshalPKCS1PrivateKey(keyPair.Private)
 	publicKeyBytes := x509.MarshalPKCS1PublicKey(keyPair.Public)
 
@@ -39,9 +142,12 @@ func (m *RSAMarshaler) Marshal(keyPair RSAKeyPair) ([]byte, []byte, error) {
 	return encodePublic, encodedPrivate, nil
 }
 
-// Unmarshal takes an encoded RSA private key and transforms it into a rsa.PrivateKey.
-func (m *RSAMarshaler) Unmarshal(privateKeyBytes []byte) (*RSAKeyPair, error) {
+// Decode takes an encoded RSA private key and transforms it into an RSAKeyPair.
+func (m RSAMarshaler) Decode(privateKeyBytes []byte) (*RSAKeyPair, error) {
 	block, _ := pem.Decode(privateKeyBytes)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
 	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if err != nil {
 		return nil, err
@@ -52,3 +158,142 @@ func (m *RSAMarshaler) Unmarshal(privateKeyBytes []byte) (*RSAKeyPair, error) {
 		Public:  &privateKey.PublicKey,
 	}, nil
 }
+
+// Marshal encodes the key pair of an RSASigner.
+func (m RSAMarshaler) Marshal(signer Signer) ([]byte, []byte, error) {
+	rsaSigner, ok := signer.(*RSASigner)
+	if !ok {
+		return nil, nil, ErrUnsupportedKey
+	}
+	return m.Encode(rsaSigner.KeyPair)
+}
+
+// Unmarshal creates an RSASigner from an encoded private key.
+func (m RSAMarshaler) Unmarshal(privateKeyBytes []byte, scheme SignatureScheme) (Signer, error) {
+	keyPair, err := m.Decode(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewRSASignerWithScheme(*keyPair, scheme), nil
+}
+
+// RSASigner is a concrete implementation of the Signer interface for RSA keys.
+type RSASigner struct {
+	KeyPair         RSAKeyPair
+	SignatureScheme SignatureScheme
+}
+
+// NewRSASigner is a factory to instantiate a new RSASigner using RSA-PSS with SHA-256.
+func NewRSASigner(keyPair RSAKeyPair) *RSASigner {
+	return NewRSASignerWithScheme(keyPair, DefaultRSAScheme)
+}
+
+// NewRSASignerWithScheme is a factory to instantiate a new RSASigner using the given signature scheme.
+func NewRSASignerWithScheme(keyPair RSAKeyPair, scheme SignatureScheme) *RSASigner {
+	return &RSASigner{
+		KeyPair:         keyPair,
+		SignatureScheme: scheme,
+	}
+}
+
+// Sign signs the given data with the RSA private key.
+func (s *RSASigner) Sign(dataToBeSigned []byte) ([]byte, error) {
+	return s.sign(dataToBeSigned, nil)
+}
+
+// sign hashes and signs the data with the scheme of the signer. The PSS
+// options are only used for PSS padding, nil selects the maximum salt length.
+func (s *RSASigner) sign(dataToBeSigned []byte, pssOptions *rsa.PSSOptions) ([]byte, error) {
+	hash, hashed, err := s.SignatureScheme.Digest(dataToBeSigned)
+	if err != nil {
+		return nil, err
+	}
+	if s.SignatureScheme.Padding == PADDING_PKCS1V15 {
+		return rsa.SignPKCS1v15(rand.Reader, s.KeyPair.Private, hash, hashed)
+	}
+	signature, err := rsa.SignPSS(rand.Reader, s.KeyPair.Private, hash, hashed, pssOptions)
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// Algorithm returns the name of the algorithm used by the signer.
+func (s *RSASigner) Algorithm() string {
+	return ALGORITHM_RSA
+}
+
+// Public returns the RSA public key.
+func (s *RSASigner) Public() crypto.PublicKey {
+	return s.KeyPair.Public
+}
+
+// KeyParameters returns the modulus size of the RSA key.
+func (s *RSASigner) KeyParameters() KeyParameters {
+	return KeyParameters{Size: s.KeyPair.Public.N.BitLen()}
+}
+
+// Scheme returns the hash and padding used by the signer.
+func (s *RSASigner) Scheme() SignatureScheme {
+	return s.SignatureScheme
+}
+
+// JWSAlgorithm returns RS256, RS384, RS512 for PKCS#1 v1.5 and PS256, PS384, PS512 for PSS signatures.
+func (s *RSASigner) JWSAlgorithm() (string, error) {
+	prefix := "PS"
+	if s.SignatureScheme.Padding == PADDING_PKCS1V15 {
+		prefix = "RS"
+	}
+	switch s.SignatureScheme.Hash {
+	case HASH_SHA256:
+		return prefix + "256", nil
+	case HASH_SHA384:
+		return prefix + "384", nil
+	case HASH_SHA512:
+		return prefix + "512", nil
+	default:
+		return "", ErrInvalidSignatureScheme
+	}
+}
+
+// SignJWS signs the JWS signing input with the scheme of the signer. PSS
+// signatures use a salt as long as the hash, as required by RFC 7518 section 3.5.
+func (s *RSASigner) SignJWS(signingInput []byte) ([]byte, error) {
+	return s.sign(signingInput, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+}
+
+// RSAVerifier is a concrete implementation of the Verifier interface for RSA keys.
+type RSAVerifier struct {
+	PublicKey       *rsa.PublicKey
+	SignatureScheme SignatureScheme
+}
+
+// NewRSAVerifier is a factory to instantiate a new RSAVerifier for RSA-PSS with SHA-256.
+func NewRSAVerifier(publicKey *rsa.PublicKey) *RSAVerifier {
+	return NewRSAVerifierWithScheme(publicKey, DefaultRSAScheme)
+}
+
+// NewRSAVerifierWithScheme is a factory to instantiate a new RSAVerifier for the given signature scheme.
+func NewRSAVerifierWithScheme(publicKey *rsa.PublicKey, scheme SignatureScheme) *RSAVerifier {
+	return &RSAVerifier{
+		PublicKey:       publicKey,
+		SignatureScheme: scheme,
+	}
+}
+
+// Verify checks an RSA signature over the hash of the signed data.
+func (v *RSAVerifier) Verify(signedData []byte, signature []byte) error {
+	hash, hashed, err := v.SignatureScheme.Digest(signedData)
+	if err != nil {
+		return err
+	}
+	if v.SignatureScheme.Padding == PADDING_PKCS1V15 {
+		err = rsa.VerifyPKCS1v15(v.PublicKey, hash, hashed, signature)
+	} else {
+		err = rsa.VerifyPSS(v.PublicKey, hash, hashed, signature, nil)
+	}
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
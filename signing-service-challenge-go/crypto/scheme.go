@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSignatureScheme is an error for signature schemes an algorithm does not support.
+var ErrInvalidSignatureScheme = errors.New("invalid signature scheme")
+
+const (
+	HASH_SHA256 = "SHA-256"
+	HASH_SHA384 = "SHA-384"
+	HASH_SHA512 = "SHA-512"
+)
+
+const (
+	PADDING_PSS      = "PSS"
+	PADDING_PKCS1V15 = "PKCS1v15"
+)
+
+var hashes = map[string]crypto.Hash{
+	HASH_SHA256: crypto.SHA256,
+	HASH_SHA384: crypto.SHA384,
+	HASH_SHA512: crypto.SHA512,
+}
+
+// SignatureScheme configures how a signer hashes and pads the data to be signed.
+// Settings that do not apply to an algorithm are left empty.
+type SignatureScheme struct {
+	// Hash is the name of the hash function, e.g. SHA-256.
+	Hash string
+	// Padding is the RSA signature padding, PSS or PKCS1v15.
+	Padding string
+	// Deterministic derives the ECDSA nonce from the key and data (RFC 6979)
+	// instead of drawing it at random.
+	Deterministic bool
+}
+
+// Digest hashes the data with the hash function of the scheme.
+func (s SignatureScheme) Digest(data []byte) (crypto.Hash, []byte, error) {
+	hash, ok := hashes[s.Hash]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: unknown hash %q", ErrInvalidSignatureScheme, s.Hash)
+	}
+	h := hash.New()
+	_, err := h.Write(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	return hash, h.Sum(nil), nil
+}
+
+// validateHash fills in SHA-256 as default hash and rejects unknown hash functions.
+func validateHash(requested SignatureScheme) (SignatureScheme, error) {
+	if requested.Hash == "" {
+		requested.Hash = HASH_SHA256
+	}
+	if _, ok := hashes[requested.Hash]; !ok {
+		return SignatureScheme{}, fmt.Errorf("%w: hash must be one of SHA-256, SHA-384, SHA-512", ErrInvalidSignatureScheme)
+	}
+	return requested, nil
+}
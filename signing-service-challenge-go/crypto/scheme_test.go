@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestSignatureSchemes(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		scheme    SignatureScheme
+	}{
+		{ALGORITHM_RSA, SignatureScheme{Hash: HASH_SHA256, Padding: PADDING_PSS}},
+		{ALGORITHM_RSA, SignatureScheme{Hash: HASH_SHA384, Padding: PADDING_PSS}},
+		{ALGORITHM_RSA, SignatureScheme{Hash: HASH_SHA512, Padding: PADDING_PKCS1V15}},
+		{ALGORITHM_ECC, SignatureScheme{Hash: HASH_SHA384}},
+		{ALGORITHM_ECC, SignatureScheme{Hash: HASH_SHA512}},
+	}
+	for _, test := range tests {
+		t.Run(test.algorithm+" "+test.scheme.Hash+" "+test.scheme.Padding, func(t *testing.T) {
+			signer, err := CreateSignerWithParameters(test.algorithm, KeyParameters{}, test.scheme)
+			if err != nil {
+				t.Fatal("Error while creating signer, got:", err)
+			}
+			if signer.Scheme() != test.scheme {
+				t.Errorf("Expected scheme %+v, got %+v", test.scheme, signer.Scheme())
+			}
+
+			message := []byte("test_data")
+			signature, err := signer.Sign(message)
+			if err != nil {
+				t.Fatal("Error while signing, got:", err)
+			}
+			verifier, _ := CreateVerifier(test.algorithm, signer.Public(), test.scheme)
+			if err := verifier.Verify(message, signature); err != nil {
+				t.Error("Error while verifying, got:", err)
+			}
+			defaultVerifier, _ := CreateVerifier(test.algorithm, signer.Public(), SignatureScheme{})
+			if test.scheme.Hash != HASH_SHA256 && defaultVerifier.Verify(message, signature) == nil {
+				t.Error("Expected signature to be bound to its scheme")
+			}
+		})
+	}
+
+	t.Run("RSA PKCS1v15 SHA-256", func(t *testing.T) {
+		signer, _ := CreateSignerWithParameters(ALGORITHM_RSA, KeyParameters{}, SignatureScheme{Padding: PADDING_PKCS1V15})
+		message := []byte("test_data")
+		signature, _ := signer.Sign(message)
+
+		msgHashSum := sha256.Sum256(message)
+		err := rsa.VerifyPKCS1v15(signer.Public().(*rsa.PublicKey), crypto.SHA256, msgHashSum[:], signature)
+		if err != nil {
+			t.Error("Error while verifying, got:", err)
+		}
+	})
+
+	t.Run("Unsupported schemes", func(t *testing.T) {
+		unsupported := []struct {
+			algorithm string
+			scheme    SignatureScheme
+		}{
+			{ALGORITHM_RSA, SignatureScheme{Hash: "MD5"}},
+			{ALGORITHM_RSA, SignatureScheme{Padding: "OAEP"}},
+			{ALGORITHM_RSA, SignatureScheme{Deterministic: true}},
+			{ALGORITHM_ECC, SignatureScheme{Padding: PADDING_PSS}},
+			{ALGORITHM_ED25519, SignatureScheme{Hash: HASH_SHA256}},
+		}
+		for _, test := range unsupported {
+			algorithm, _ := GetAlgorithm(test.algorithm)
+			if _, err := algorithm.Scheme(test.scheme); !errors.Is(err, ErrInvalidSignatureScheme) {
+				t.Errorf("%s %+v: expected ErrInvalidSignatureScheme, got %v", test.algorithm, test.scheme, err)
+			}
+		}
+	})
+}
@@ -2,104 +2,38 @@ package crypto
 
 import (
 	"crypto"
-	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/sha256"
-	"errors"
 )
 
-// To add a new algorithm, you need to:
-// 1. Add a new constant for the algorithm.
-// 2. Implement a struct that implements the Signer interface.
-// 3. Extend the factory function to return the new implementation based on the given algorithm.
-
-// ALGORITHM_RSA is a constant for the RSA algorithm.
-const ALGORITHM_RSA = "RSA"
-
-// ALGORITHM_ECC is a constant for the ECC algorithm.
-const ALGORITHM_ECC = "ECC"
-
-// ErrUnknownAlgorithm is an error for unknown algorithms.
-var ErrUnknownAlgorithm = errors.New("unknown algorithm")
-
 // Signer defines a contract for different types of signing implementations.
 type Signer interface {
 	Sign(dataToBeSigned []byte) ([]byte, error)
+	Algorithm() string
+	Public() crypto.PublicKey
+	KeyParameters() KeyParameters
+	Scheme() SignatureScheme
 }
 
-// RSASigner is a concrete implementation of the Signer interface for RSA keys.
-type RSASigner struct {
-	KeyPair RSAKeyPair
-}
-
-// NewRSASigner is a factory to instantiate a new RSASigner.
-func NewRSASigner(keyPair RSAKeyPair) *RSASigner {
-	return &RSASigner{
-		KeyPair: keyPair,
-	}
+// CreateSigner is a factory to instantiate a new Signer with a freshly generated
+// key pair for the given algorithm, using the default key parameters and scheme.
+func CreateSigner(algorithm string) (Signer, error) {
+	return CreateSignerWithParameters(algorithm, KeyParameters{}, SignatureScheme{})
 }
 
-// Sign signs the given data with the RSA private key.
-func (s *RSASigner) Sign(dataToBeSigned []byte) ([]byte, error) {
-	hash := sha256.New()
-	_, err := hash.Write(dataToBeSigned)
-	if err != nil {
-		return nil, err
-	}
-	hashed := hash.Sum(nil)
-	signature, err := rsa.SignPSS(rand.Reader, s.KeyPair.Private, crypto.SHA256, hashed, nil)
+// CreateSignerWithParameters is a factory to instantiate a new Signer with a
+// freshly generated key pair for the given algorithm, key parameters and
+// signature scheme. Settings that are not set fall back to the defaults of the algorithm.
+func CreateSignerWithParameters(algorithm string, parameters KeyParameters, scheme SignatureScheme) (Signer, error) {
+	a, err := GetAlgorithm(algorithm)
 	if err != nil {
 		return nil, err
 	}
-	return signature, nil
-}
-
-// ECDSASigner is a concrete implementation of the Signer interface for ECC keys.
-type ECDSASigner struct {
-	KeyPair ECCKeyPair
-}
-
-// NewECDSASigner is a factory to instantiate a new ECDSASigner.
-func NewECDSASigner(keyPair ECCKeyPair) *ECDSASigner {
-	return &ECDSASigner{
-		KeyPair: keyPair,
-	}
-}
-
-// Sign signs the given data with the ECC private key.
-func (s *ECDSASigner) Sign(dataToBeSigned []byte) ([]byte, error) {
-	hash := sha256.New()
-	_, err := hash.Write(dataToBeSigned)
+	parameters, err = a.Parameters(parameters)
 	if err != nil {
 		return nil, err
 	}
-	hashed := hash.Sum(nil)
-	signature, err := ecdsa.SignASN1(rand.Reader, s.KeyPair.Private, hashed)
+	scheme, err = a.Scheme(scheme)
 	if err != nil {
 		return nil, err
 	}
-	return signature, nil
-}
-
-// CreateSigner is a factory to instantiate a new Signer based on the given algorithm.
-func CreateSigner(algorithm string) (Signer, error) {
-	switch algorithm {
-	case ALGORITHM_RSA:
-		keyGenerator := RSAGenerator{}
-		keyPair, err := keyGenerator.Generate()
-		if err != nil {
-			return nil, err
-		}
-		return NewRSASigner(*keyPair), nil
-	case ALGORITHM_ECC:
-		keyGenerator := ECCGenerator{}
-		keyPair, err := keyGenerator.Generate()
-		if err != nil {
-			return nil, err
-		}
-		return NewECDSASigner(*keyPair), nil
-	default:
-		return nil, ErrUnknownAlgorithm
-	}
+	return a.Generate(parameters, scheme)
 }
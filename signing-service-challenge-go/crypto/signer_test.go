@@ -1,10 +1,14 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
 	"testing"
 )
 
@@ -42,3 +46,52 @@ func TestECDSASigner_Sign(t *testing.T) {
 		t.Error("Signature verification failed")
 	}
 }
+
+// Test vector from RFC 6979 appendix A.2.5 (ECDSA, 256 bits, SHA-256, message "sample").
+func TestECDSASigner_DeterministicRFC6979Vector(t *testing.T) {
+	d, _ := new(big.Int).SetString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", 16)
+	privateKey := &ecdsa.PrivateKey{D: d}
+	privateKey.Curve = elliptic.P256()
+	privateKey.X, privateKey.Y = elliptic.P256().ScalarBaseMult(d.Bytes())
+
+	keyPair := ECCKeyPair{Public: &privateKey.PublicKey, Private: privateKey}
+	signer := NewECDSASignerWithScheme(keyPair, SignatureScheme{Hash: HASH_SHA256, Deterministic: true})
+	signature, err := signer.Sign([]byte("sample"))
+	if err != nil {
+		t.Fatal("Error while signing, got:", err)
+	}
+
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(signature, &rs); err != nil {
+		t.Fatal("Error while decoding signature, got:", err)
+	}
+	expectedR, _ := new(big.Int).SetString("EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716", 16)
+	expectedS, _ := new(big.Int).SetString("F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8", 16)
+	if rs.R.Cmp(expectedR) != 0 || rs.S.Cmp(expectedS) != 0 {
+		t.Errorf("Expected r=%X s=%X, got r=%X s=%X", expectedR, expectedS, rs.R, rs.S)
+	}
+}
+
+func TestECDSASigner_Deterministic(t *testing.T) {
+	for _, hash := range []string{HASH_SHA256, HASH_SHA384, HASH_SHA512} {
+		t.Run(hash, func(t *testing.T) {
+			scheme := SignatureScheme{Hash: hash, Deterministic: true}
+			signer, err := CreateSignerWithParameters(ALGORITHM_ECC, KeyParameters{}, scheme)
+			if err != nil {
+				t.Fatal("Error while creating signer, got:", err)
+			}
+			message := []byte("test_data")
+
+			first, _ := signer.Sign(message)
+			second, _ := signer.Sign(message)
+			if !bytes.Equal(first, second) {
+				t.Error("Expected deterministic signatures to be equal")
+			}
+
+			_, hashed, _ := scheme.Digest(message)
+			if !ecdsa.VerifyASN1(signer.Public().(*ecdsa.PublicKey), hashed, first) {
+				t.Error("Signature verification failed")
+			}
+		})
+	}
+}
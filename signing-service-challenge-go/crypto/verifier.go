@@ -0,0 +1,28 @@
+package crypto
+
+import (
+	"crypto"
+	"errors"
+)
+
+// ErrInvalidSignature is an error for signatures that do not match the signed data.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Verifier defines a contract for verifying signatures created by a Signer.
+type Verifier interface {
+	Verify(signedData []byte, signature []byte) error
+}
+
+// CreateVerifier is a factory to instantiate a new Verifier for a public key
+// and signature scheme of the given algorithm.
+func CreateVerifier(algorithm string, publicKey crypto.PublicKey, scheme SignatureScheme) (Verifier, error) {
+	a, err := GetAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err = a.Scheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return a.Verifier(publicKey, scheme)
+}
@@ -0,0 +1,32 @@
+package crypto
+
+import (
+	"testing"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	for _, algorithm := range []string{ALGORITHM_RSA, ALGORITHM_ECC} {
+		t.Run(algorithm, func(t *testing.T) {
+			signer, err := CreateSigner(algorithm)
+			if err != nil {
+				t.Fatal("Error while creating signer, got:", err)
+			}
+			verifier, err := CreateVerifier(algorithm, signer.Public(), signer.Scheme())
+			if err != nil {
+				t.Fatal("Error while creating verifier, got:", err)
+			}
+			message := []byte("test_data")
+			signature, err := signer.Sign(message)
+			if err != nil {
+				t.Fatal("Error while signing, got:", err)
+			}
+
+			if err := verifier.Verify(message, signature); err != nil {
+				t.Error("Error while verifying, got:", err)
+			}
+			if err := verifier.Verify([]byte("other_data"), signature); err != ErrInvalidSignature {
+				t.Error("Expected ErrInvalidSignature, got:", err)
+			}
+		})
+	}
+}
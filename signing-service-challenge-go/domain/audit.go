@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// AuditReport is the result of checking the signature chain of a device
+type AuditReport struct {
+	DeviceId       string
+	SignatureCount int
+	Valid          bool
+	// BrokenAt is the position of the first signature that breaks the chain, or -1
+	BrokenAt int
+	Reason   string
+}
+
+// Audit walks the given signatures from counter 0 and checks that they form an
+// unbroken chain created by the device. It reports the first break it finds.
+// Only signatures up to the signature counter of the device are audited, so the
+// device must be read before its signatures. Signatures created in the meantime
+// are left for the next audit instead of being reported as a break.
+func (d *SignatureDevice) Audit(signatures []*Signature) *AuditReport {
+	counter := d.SignatureCounter()
+	if len(signatures) > counter {
+		signatures = signatures[:counter]
+	}
+	report := &AuditReport{
+		DeviceId:       d.Id,
+		SignatureCount: len(signatures),
+		Valid:          true,
+		BrokenAt:       -1,
+	}
+
+	last_signature := base64.StdEncoding.EncodeToString([]byte(d.Id))
+	for position, signature := range signatures {
+		if reason := d.auditSignature(position, signature, last_signature); reason != "" {
+			report.Valid = false
+			report.BrokenAt = position
+			report.Reason = reason
+			return report
+		}
+		last_signature = signature.Signature
+	}
+
+	if len(signatures) < counter {
+		report.Valid = false
+		report.BrokenAt = len(signatures)
+		report.Reason = fmt.Sprintf("device has created %d signatures but %d are stored", counter, len(signatures))
+	}
+	return report
+}
+
+func (d *SignatureDevice) auditSignature(position int, signature *Signature, last_signature string) string {
+	if signature.Counter != position {
+		return fmt.Sprintf("expected counter %d but got %d", position, signature.Counter)
+	}
+
+	secured_data, err := ParseSecuredData(signature.Signed_Data)
+	if err != nil {
+		return "signed data is malformed"
+	}
+	if secured_data.Counter != position {
+		return fmt.Sprintf("signed data does not embed counter %d", position)
+	}
+	switch secured_data.Framing {
+	case FRAMING_V1:
+		if secured_data.DeviceId != d.Id {
+			return fmt.Sprintf("signed data belongs to device %s", secured_data.DeviceId)
+		}
+		if !bytes.Equal(secured_data.PreviousSignatureHash, hashPreviousSignature(last_signature)) {
+			return "signed data does not embed the previous signature"
+		}
+		if secured_data.DataMode != signature.DataMode {
+			return fmt.Sprintf("signed data embeds data mode %s but the signature was stored as %s", secured_data.DataMode, signature.DataMode)
+		}
+	default:
+		if secured_data.PreviousSignature != last_signature {
+			return "signed data does not embed the previous signature"
+		}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return "signature is not valid base64"
+	}
+	if err := d.Verify(signature.Signed_Data, decoded); err != nil {
+		return fmt.Sprintf("signature does not verify: %v", err)
+	}
+	return ""
+}
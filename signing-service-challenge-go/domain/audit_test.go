@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+func TestAudit(t *testing.T) {
+	signer, err := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	if err != nil {
+		t.Fatal("Error while creating signer, got:", err)
+	}
+	device := NewSignatureDevice("id", "label", signer)
+
+	signatures := make([]*Signature, 0)
+	for _, data := range []string{"first", "second", "third"} {
+		signature, err := device.Sign(data)
+		if err != nil {
+			t.Fatal("Error while signing, got:", err)
+		}
+		signatures = append(signatures, signature)
+	}
+
+	t.Run("Unbroken chain", func(t *testing.T) {
+		report := device.Audit(signatures)
+		if !report.Valid {
+			t.Error("Expected chain to be valid, but got:", report.Reason)
+		}
+		if report.SignatureCount != 3 {
+			t.Error("Expected 3 signatures, but got", report.SignatureCount)
+		}
+	})
+
+	t.Run("Missing signature", func(t *testing.T) {
+		report := device.Audit([]*Signature{signatures[0], signatures[2]})
+		if report.Valid || report.BrokenAt != 1 {
+			t.Error("Expected chain to break at position 1, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Tampered signed data", func(t *testing.T) {
+		tampered := *signatures[1]
+		tampered.Signed_Data = []byte("1_tampered_" + signatures[0].Signature)
+		report := device.Audit([]*Signature{signatures[0], &tampered, signatures[2]})
+		if report.Valid || report.BrokenAt != 1 {
+			t.Error("Expected chain to break at position 1, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Truncated chain", func(t *testing.T) {
+		report := device.Audit(signatures[:2])
+		if report.Valid || report.BrokenAt != 2 {
+			t.Error("Expected chain to break at position 2, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Wrong previous signature", func(t *testing.T) {
+		report := device.Audit([]*Signature{signatures[1]})
+		if report.Valid || report.BrokenAt != 0 {
+			t.Error("Expected chain to break at position 0, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Signatures newer than the device state", func(t *testing.T) {
+		snapshot := device.Clone()
+		newer, err := device.Sign("fourth")
+		if err != nil {
+			t.Fatal("Error while signing, got:", err)
+		}
+		report := snapshot.Audit([]*Signature{signatures[0], signatures[1], signatures[2], newer})
+		if !report.Valid || report.SignatureCount != 3 {
+			t.Error("Expected 3 valid signatures, but got", report.SignatureCount, report.Reason)
+		}
+	})
+}
+
+func TestAudit_V1Framing(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device, err := NewSignatureDeviceWithFraming("id", "label", signer, FRAMING_V1)
+	if err != nil {
+		t.Fatal("Error while creating device, got:", err)
+	}
+
+	signatures := make([]*Signature, 0)
+	for _, data := range []string{"first_", "_second", "third"} {
+		signature, err := device.Sign(data)
+		if err != nil {
+			t.Fatal("Error while signing, got:", err)
+		}
+		signatures = append(signatures, signature)
+	}
+
+	t.Run("Unbroken chain", func(t *testing.T) {
+		report := device.Audit(signatures)
+		if !report.Valid {
+			t.Error("Expected chain to be valid, but got:", report.Reason)
+		}
+	})
+
+	t.Run("Missing signature", func(t *testing.T) {
+		report := device.Audit([]*Signature{signatures[0], signatures[2]})
+		if report.Valid || report.BrokenAt != 1 {
+			t.Error("Expected chain to break at position 1, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Foreign device", func(t *testing.T) {
+		other, _ := NewSignatureDeviceWithFraming("other", "label", signer, FRAMING_V1)
+		signature, _ := other.Sign("first_")
+		report := device.Audit([]*Signature{signature})
+		if report.Valid || report.BrokenAt != 0 {
+			t.Error("Expected chain to break at position 0, but got", report.BrokenAt)
+		}
+	})
+
+	t.Run("Wrong data mode", func(t *testing.T) {
+		tampered := *signatures[1]
+		tampered.DataMode = DATA_MODE_SHA256_DIGEST
+		report := device.Audit([]*Signature{signatures[0], &tampered, signatures[2]})
+		if report.Valid || report.BrokenAt != 1 {
+			t.Error("Expected chain to break at position 1, but got", report.BrokenAt)
+		}
+	})
+}
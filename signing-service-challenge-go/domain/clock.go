@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// Clock provides the time a signature is created at
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the time from the system clock in UTC
+type SystemClock struct{}
+
+// Now returns the current system time in UTC
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// FixedClock always returns the same time, e.g. for reproducible signatures in tests
+type FixedClock struct {
+	Time time.Time
+}
+
+// Now returns the fixed time
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+func TestSign_Clock(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device, _ := NewSignatureDeviceWithFraming("id", "label", signer, FRAMING_V1)
+	clock := FixedClock{Time: time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("CET", 3600))}
+
+	signature, err := device.SignWithOptions([]byte("test_data"), SignOptions{Clock: clock})
+	if err != nil {
+		t.Fatal("Error while signing, got:", err)
+	}
+
+	if !signature.Timestamp.Equal(clock.Time) || signature.Timestamp.Location() != time.UTC {
+		t.Error("Expected timestamp", clock.Time.UTC(), "but got", signature.Timestamp)
+	}
+	secured_data, _ := ParseSecuredData(signature.Signed_Data)
+	if !secured_data.Timestamp.Equal(clock.Time) {
+		t.Error("Expected signed timestamp", clock.Time.UTC(), "but got", secured_data.Timestamp)
+	}
+}
@@ -1,17 +1,50 @@
 package domain
 
 import (
+	gocrypto "crypto"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
 )
 
-// Signature represents a signature
+// DATA_MODE_RAW marks signatures over the full data to be signed.
+const DATA_MODE_RAW = "raw"
+
+// DATA_MODE_SHA256_DIGEST marks signatures over a SHA-256 digest of the data,
+// embedded as lowercase hex in the secured data.
+const DATA_MODE_SHA256_DIGEST = "sha256-digest"
+
+// ErrInvalidDigest is returned when pre-hashed data is not a hex encoded SHA-256 digest
+var ErrInvalidDigest = errors.New("data is not a hex encoded SHA-256 digest")
+
+// NormalizeDigest checks that data is a hex encoded SHA-256 digest and returns it
+// in lowercase hex, which is how digests are embedded in the secured data
+func NormalizeDigest(data []byte) ([]byte, error) {
+	digest, err := hex.DecodeString(string(data))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, ErrInvalidDigest
+	}
+	return []byte(hex.EncodeToString(digest)), nil
+}
+
+// Signature represents a signature created by a signature device
 type Signature struct {
+	DeviceId    string
+	Counter     int
+	Data        []byte
+	DataMode    string
 	Signature   string
-	Signed_Data string
+	Signed_Data []byte
+	JWS         string
+	Timestamp   time.Time
+	// IdempotencyKey is the client supplied key the signature was requested with, if any
+	IdempotencyKey string
 }
 
 // SignatureDevice represents a signature device
@@ -21,37 +54,205 @@ type SignatureDevice struct {
 	signer            crypto.Signer
 	signature_counter int
 	last_signature    string
+	framing           string
+	created_at        time.Time
 	mu                sync.Mutex
 }
 
-// NewSignatureDevice creates a new signature device
+// NewSignatureDevice creates a new signature device with legacy framing
 func NewSignatureDevice(id string, label string, signer crypto.Signer) *SignatureDevice {
 	return &SignatureDevice{
-		Id:     id,
-		Label:  label,
-		signer: signer,
+		Id:         id,
+		Label:      label,
+		signer:     signer,
+		framing:    FRAMING_LEGACY,
+		created_at: time.Now().UTC(),
+	}
+}
+
+// NewSignatureDeviceWithFraming creates a new signature device that frames its secured data
+// with the given framing, legacy if no framing is given
+func NewSignatureDeviceWithFraming(id string, label string, signer crypto.Signer, framing string) (*SignatureDevice, error) {
+	framing, err := validFraming(framing)
+	if err != nil {
+		return nil, err
+	}
+	device := NewSignatureDevice(id, label, signer)
+	device.framing = framing
+	return device, nil
+}
+
+// Clone returns a copy of the device. Repositories apply updates to a copy and
+// only replace the device once the new state has been stored.
+func (d *SignatureDevice) Clone() *SignatureDevice {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &SignatureDevice{
+		Id:                d.Id,
+		Label:             d.Label,
+		signer:            d.signer,
+		signature_counter: d.signature_counter,
+		last_signature:    d.last_signature,
+		framing:           d.framing,
+		created_at:        d.created_at,
 	}
 }
 
+// Algorithm returns the name of the signature algorithm used by the device
+func (d *SignatureDevice) Algorithm() string {
+	return d.signer.Algorithm()
+}
+
+// KeyParameters returns the parameters of the key pair of the device
+func (d *SignatureDevice) KeyParameters() crypto.KeyParameters {
+	return d.signer.KeyParameters()
+}
+
+// Scheme returns the hash and padding the device signs with
+func (d *SignatureDevice) Scheme() crypto.SignatureScheme {
+	return d.signer.Scheme()
+}
+
+// Framing returns the format of the secured data signed by the device
+func (d *SignatureDevice) Framing() string {
+	return d.framing
+}
+
+// PublicKey returns the public key of the device
+func (d *SignatureDevice) PublicKey() gocrypto.PublicKey {
+	return d.signer.Public()
+}
+
+// CreatedAt returns the time the device was created
+func (d *SignatureDevice) CreatedAt() time.Time {
+	return d.created_at
+}
+
+// SignatureCounter returns the number of signatures created by the device
+func (d *SignatureDevice) SignatureCounter() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.signature_counter
+}
+
+// LastSignature returns the base64 encoded last signature created by the device
+func (d *SignatureDevice) LastSignature() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last_signature
+}
+
+// SignOptions configures a single signature
+type SignOptions struct {
+	// Digest marks the data as hex encoded SHA-256 digest of the actual payload
+	Digest bool
+	// JWS additionally wraps the secured data in a compact JWS signed with the device key
+	JWS bool
+	// Clock provides the signing time, the system clock is used if not set
+	Clock Clock
+}
+
 // Sign signs the data
 func (d *SignatureDevice) Sign(dataToBeSigned string) (*Signature, error) {
+	return d.SignWithOptions([]byte(dataToBeSigned), SignOptions{})
+}
+
+// SignWithOptions signs the exact bytes of the data with the given options
+func (d *SignatureDevice) SignWithOptions(dataToBeSigned []byte, options SignOptions) (*Signature, error) {
+	signatures, err := d.SignBatch([][]byte{dataToBeSigned}, options)
+	if err != nil {
+		return nil, err
+	}
+	return signatures[0], nil
+}
+
+// SignBatch signs all data in the given order under a single hold of the device
+// lock, so the signatures get contiguous counters and form an uninterrupted chain.
+// Either all data is signed or, if one signature fails, none.
+func (d *SignatureDevice) SignBatch(dataToBeSigned [][]byte, options SignOptions) ([]*Signature, error) {
+	dataMode := DATA_MODE_RAW
+	if options.Digest {
+		dataMode = DATA_MODE_SHA256_DIGEST
+	}
+	batch := make([][]byte, 0, len(dataToBeSigned))
+	for _, data := range dataToBeSigned {
+		if options.Digest {
+			digest, err := NormalizeDigest(data)
+			if err != nil {
+				return nil, err
+			}
+			data = digest
+		}
+		batch = append(batch, data)
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	secured_data := d.getSecuredData(dataToBeSigned)
+	signature_counter, last_signature := d.signature_counter, d.last_signature
+
+	signatures := make([]*Signature, 0, len(batch))
+	for _, data := range batch {
+		signature, err := d.sign(data, dataMode, options)
+		if err != nil {
+			d.signature_counter, d.last_signature = signature_counter, last_signature
+			return nil, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, nil
+}
+
+// sign creates the next signature of the chain, the caller must hold the device lock
+func (d *SignatureDevice) sign(dataToBeSigned []byte, dataMode string, options SignOptions) (*Signature, error) {
+	counter := d.signature_counter
+	timestamp := signingTime(options.Clock)
+	secured_data := d.getSecuredData(dataToBeSigned, dataMode, timestamp)
 	signature, err := d.signer.Sign(secured_data)
 	if err != nil {
 		return nil, err
 	}
+
+	var jws string
+	if options.JWS {
+		jws, err = crypto.CreateJWS(d.signer, d.Id, secured_data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	d.signature_counter++
 	d.last_signature = base64.StdEncoding.EncodeToString(signature)
 
 	return &Signature{
+		DeviceId:    d.Id,
+		Counter:     counter,
+		Data:        dataToBeSigned,
+		DataMode:    dataMode,
 		Signature:   d.last_signature,
-		Signed_Data: string(secured_data),
+		Signed_Data: secured_data,
+		JWS:         jws,
+		Timestamp:   timestamp,
 	}, nil
 }
 
-func (d *SignatureDevice) getSecuredData(dataToBeSigned string) []byte {
+// signingTime reads the clock in UTC, so timestamps are framed and stored alike
+func signingTime(clock Clock) time.Time {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return clock.Now().UTC()
+}
+
+// Verify checks whether the signature was created by the device for the signed data
+func (d *SignatureDevice) Verify(signedData []byte, signature []byte) error {
+	verifier, err := crypto.CreateVerifier(d.signer.Algorithm(), d.signer.Public(), d.signer.Scheme())
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(signedData, signature)
+}
+
+func (d *SignatureDevice) getSecuredData(dataToBeSigned []byte, dataMode string, timestamp time.Time) []byte {
 	var last_signature string
 
 	if d.signature_counter == 0 {
@@ -60,5 +261,14 @@ func (d *SignatureDevice) getSecuredData(dataToBeSigned string) []byte {
 		last_signature = d.last_signature
 	}
 
-	return []byte(strconv.Itoa(d.signature_counter) + "_" + dataToBeSigned + "_" + last_signature)
+	if d.framing == FRAMING_V1 {
+		return frameV1(d.Id, d.signature_counter, timestamp, last_signature, dataMode, dataToBeSigned)
+	}
+
+	secured_data := make([]byte, 0, len(dataToBeSigned)+len(last_signature)+16)
+	secured_data = strconv.AppendInt(secured_data, int64(d.signature_counter), 10)
+	secured_data = append(secured_data, '_')
+	secured_data = append(secured_data, dataToBeSigned...)
+	secured_data = append(secured_data, '_')
+	return append(secured_data, last_signature...)
 }
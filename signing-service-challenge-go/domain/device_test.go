@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"bytes"
 	gocrypto "crypto"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -31,8 +32,8 @@ func TestSign(t *testing.T) {
 		}
 
 		expected_secured_data := fmt.Sprintf("0_%s_%s", data, base64.StdEncoding.EncodeToString([]byte(device.Id)))
-		if signature.Signed_Data != expected_secured_data {
-			t.Error("Expected secured data to be", expected_secured_data, "but got", signature.Signed_Data)
+		if string(signature.Signed_Data) != expected_secured_data {
+			t.Error("Expected secured data to be", expected_secured_data, "but got", string(signature.Signed_Data))
 		}
 		if err := verifySignature(signature, rsaKeyPair.Public); err != nil {
 			t.Error("Error while verifying, got:", err)
@@ -49,17 +50,125 @@ func TestSign(t *testing.T) {
 		}
 
 		expected_secured_data := fmt.Sprintf("1_%s_%s", data, last_signature.Signature)
-		if signature.Signed_Data != expected_secured_data {
-			t.Error("Expected secured data to be", expected_secured_data, "but got", signature.Signed_Data)
+		if string(signature.Signed_Data) != expected_secured_data {
+			t.Error("Expected secured data to be", expected_secured_data, "but got", string(signature.Signed_Data))
 		}
 		if err := verifySignature(signature, rsaKeyPair.Public); err != nil {
 			t.Error("Error while verifying, got:", err)
 		}
 	})
+
+	t.Run("Device state", func(t *testing.T) {
+		if device.SignatureCounter() != 2 {
+			t.Error("Expected signature counter to be 2 but got", device.SignatureCounter())
+		}
+		if device.Algorithm() != crypto.ALGORITHM_RSA {
+			t.Error("Expected algorithm to be", crypto.ALGORITHM_RSA, "but got", device.Algorithm())
+		}
+	})
 }
 
 func verifySignature(signature *Signature, public_key *rsa.PublicKey) error {
 	signature_to_verify, _ := base64.StdEncoding.DecodeString(signature.Signature)
-	msgHashSum := sha256.Sum256([]byte(signature.Signed_Data))
+	msgHashSum := sha256.Sum256(signature.Signed_Data)
 	return rsa.VerifyPSS(public_key, gocrypto.SHA256, msgHashSum[:], signature_to_verify, nil)
 }
+
+func TestClone(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device := NewSignatureDevice("id", "label", signer)
+	device.Sign("first")
+
+	clone := device.Clone()
+	signature, err := clone.Sign("second")
+	if err != nil {
+		t.Fatal("Error while signing, got:", err)
+	}
+	if signature.Counter != 1 || clone.SignatureCounter() != 2 {
+		t.Error("Expected clone to continue the chain, but got counter", signature.Counter)
+	}
+	if device.SignatureCounter() != 1 {
+		t.Error("Expected original device to stay at counter 1 but got", device.SignatureCounter())
+	}
+}
+
+func TestSignDigest(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device := NewSignatureDevice("id", "label", signer)
+
+	t.Run("valid digest", func(t *testing.T) {
+		digest := sha256.Sum256([]byte("test_data"))
+		data := fmt.Sprintf("%X", digest)
+
+		signature, err := device.SignWithOptions([]byte(data), SignOptions{Digest: true})
+		if err != nil {
+			t.Fatal("Error while signing digest, got:", err)
+		}
+		if signature.DataMode != DATA_MODE_SHA256_DIGEST {
+			t.Error("Expected data mode to be", DATA_MODE_SHA256_DIGEST, "but got", signature.DataMode)
+		}
+		if string(signature.Data) != fmt.Sprintf("%x", digest) {
+			t.Error("Expected normalized lowercase digest but got", string(signature.Data))
+		}
+	})
+
+	t.Run("invalid digest", func(t *testing.T) {
+		_, err := device.SignWithOptions([]byte("abcd"), SignOptions{Digest: true})
+		if err != ErrInvalidDigest {
+			t.Error("Expected ErrInvalidDigest, but got", err)
+		}
+		if device.SignatureCounter() != 1 {
+			t.Error("Expected signature counter to stay at 1 but got", device.SignatureCounter())
+		}
+	})
+}
+
+func TestSignBinaryData(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device := NewSignatureDevice("id", "label", signer)
+	data := []byte{0x00, 0xff, '_', 0xc3, 0x28}
+
+	signature, err := device.SignWithOptions(data, SignOptions{})
+	if err != nil {
+		t.Fatal("Error while signing, got:", err)
+	}
+
+	expected_secured_data := append(append([]byte("0_"), data...), []byte("_"+base64.StdEncoding.EncodeToString([]byte(device.Id)))...)
+	if !bytes.Equal(signature.Signed_Data, expected_secured_data) {
+		t.Error("Expected secured data to be", expected_secured_data, "but got", signature.Signed_Data)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(signature.Signature)
+	if err := device.Verify(signature.Signed_Data, decoded); err != nil {
+		t.Error("Error while verifying, got:", err)
+	}
+}
+
+func TestSignBatch(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	device := NewSignatureDevice("id", "label", signer)
+
+	t.Run("contiguous counters", func(t *testing.T) {
+		signatures, err := device.SignBatch([][]byte{[]byte("first"), []byte("second")}, SignOptions{})
+		if err != nil {
+			t.Fatal("Error while signing batch, got:", err)
+		}
+		if len(signatures) != 2 || signatures[0].Counter != 0 || signatures[1].Counter != 1 {
+			t.Fatal("Expected signatures with counters 0 and 1")
+		}
+		expected_secured_data := fmt.Sprintf("1_second_%s", signatures[0].Signature)
+		if string(signatures[1].Signed_Data) != expected_secured_data {
+			t.Error("Expected secured data to be", expected_secured_data, "but got", string(signatures[1].Signed_Data))
+		}
+	})
+
+	t.Run("invalid digest signs nothing", func(t *testing.T) {
+		digest := sha256.Sum256([]byte("data"))
+		_, err := device.SignBatch([][]byte{[]byte(fmt.Sprintf("%x", digest)), []byte("abcd")}, SignOptions{Digest: true})
+		if err != ErrInvalidDigest {
+			t.Error("Expected ErrInvalidDigest, but got", err)
+		}
+		if device.SignatureCounter() != 2 {
+			t.Error("Expected signature counter to stay at 2 but got", device.SignatureCounter())
+		}
+	})
+}
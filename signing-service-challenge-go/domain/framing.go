@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// FRAMING_LEGACY is the original `<counter>_<data>_<last_signature>` secured data format.
+// It cannot be split reliably when the data contains underscores. It does not
+// embed the data mode either, so a signature over a hex encoded digest cannot be
+// told apart from a signature over the same hex string as raw data.
+const FRAMING_LEGACY = "legacy"
+
+// FRAMING_V1 encodes every component of the secured data as netstring
+// (`<length>:<bytes>,`), in the order: version, device id, counter, timestamp,
+// hex encoded SHA-256 hash of the previous signature, data mode and data.
+const FRAMING_V1 = "v1"
+
+// ErrUnsupportedFraming is returned for unknown secured data framings
+var ErrUnsupportedFraming = errors.New("unsupported secured data framing")
+
+// ErrMalformedSecuredData is returned when signed data cannot be parsed
+var ErrMalformedSecuredData = errors.New("malformed secured data")
+
+// SecuredData are the components of the data signed by a signature device
+type SecuredData struct {
+	Framing string
+	// DeviceId is only embedded by v1 framing
+	DeviceId string
+	Counter  int
+	// Timestamp is only embedded by v1 framing
+	Timestamp time.Time
+	// PreviousSignature is the base64 encoded previous signature embedded by
+	// legacy framing, or the base64 encoded device id for the first signature
+	PreviousSignature string
+	// PreviousSignatureHash is the SHA-256 hash of the previous signature embedded
+	// by v1 framing, or the hash of the device id for the first signature
+	PreviousSignatureHash []byte
+	// DataMode is only embedded by v1 framing
+	DataMode string
+	Data     []byte
+}
+
+var v1Tag = []byte("2:" + FRAMING_V1 + ",")
+
+// validFraming normalizes the framing name and reports whether it is supported
+func validFraming(framing string) (string, error) {
+	switch framing {
+	case "", FRAMING_LEGACY:
+		return FRAMING_LEGACY, nil
+	case FRAMING_V1:
+		return FRAMING_V1, nil
+	default:
+		return "", ErrUnsupportedFraming
+	}
+}
+
+// TimestampSigned reports whether the secured data of the signature covers its
+// timestamp, which only v1 framing does
+func (s *Signature) TimestampSigned() bool {
+	return bytes.HasPrefix(s.Signed_Data, v1Tag)
+}
+
+// ParseSecuredData splits signed data back into its components. The framing
+// is detected from the data itself.
+func ParseSecuredData(signedData []byte) (*SecuredData, error) {
+	if bytes.HasPrefix(signedData, v1Tag) {
+		return parseV1(signedData[len(v1Tag):])
+	}
+	return parseLegacy(signedData)
+}
+
+// parseLegacy relies on the counter and the base64 encoded previous signature
+// never containing underscores, so everything in between is data
+func parseLegacy(signedData []byte) (*SecuredData, error) {
+	first := bytes.IndexByte(signedData, '_')
+	last := bytes.LastIndexByte(signedData, '_')
+	if first < 0 || first == last {
+		return nil, ErrMalformedSecuredData
+	}
+	counter, err := strconv.Atoi(string(signedData[:first]))
+	if err != nil || counter < 0 {
+		return nil, ErrMalformedSecuredData
+	}
+	return &SecuredData{
+		Framing:           FRAMING_LEGACY,
+		Counter:           counter,
+		PreviousSignature: string(signedData[last+1:]),
+		Data:              signedData[first+1 : last],
+	}, nil
+}
+
+func parseV1(signedData []byte) (*SecuredData, error) {
+	fields := make([][]byte, 0, 6)
+	for len(signedData) > 0 {
+		field, rest, err := readNetstring(signedData)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		signedData = rest
+	}
+	if len(fields) != 6 {
+		return nil, ErrMalformedSecuredData
+	}
+
+	counter, err := strconv.Atoi(string(fields[1]))
+	if err != nil || counter < 0 {
+		return nil, ErrMalformedSecuredData
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, string(fields[2]))
+	if err != nil {
+		return nil, ErrMalformedSecuredData
+	}
+	previousSignatureHash, err := hex.DecodeString(string(fields[3]))
+	if err != nil || len(previousSignatureHash) != sha256.Size {
+		return nil, ErrMalformedSecuredData
+	}
+	dataMode := string(fields[4])
+	if dataMode != DATA_MODE_RAW && dataMode != DATA_MODE_SHA256_DIGEST {
+		return nil, ErrMalformedSecuredData
+	}
+	return &SecuredData{
+		Framing:               FRAMING_V1,
+		DeviceId:              string(fields[0]),
+		Counter:               counter,
+		Timestamp:             timestamp,
+		PreviousSignatureHash: previousSignatureHash,
+		DataMode:              dataMode,
+		Data:                  fields[5],
+	}, nil
+}
+
+func frameV1(deviceId string, counter int, timestamp time.Time, previousSignature string, dataMode string, data []byte) []byte {
+	secured_data := append([]byte{}, v1Tag...)
+	secured_data = appendNetstring(secured_data, []byte(deviceId))
+	secured_data = appendNetstring(secured_data, []byte(strconv.Itoa(counter)))
+	secured_data = appendNetstring(secured_data, []byte(timestamp.UTC().Format(time.RFC3339Nano)))
+	secured_data = appendNetstring(secured_data, []byte(hex.EncodeToString(hashPreviousSignature(previousSignature))))
+	secured_data = appendNetstring(secured_data, []byte(dataMode))
+	return appendNetstring(secured_data, data)
+}
+
+// hashPreviousSignature hashes the raw bytes of a base64 encoded previous signature,
+// which the device always stores in valid base64
+func hashPreviousSignature(previousSignature string) []byte {
+	decoded, _ := base64.StdEncoding.DecodeString(previousSignature)
+	hash := sha256.Sum256(decoded)
+	return hash[:]
+}
+
+func appendNetstring(buffer []byte, field []byte) []byte {
+	buffer = strconv.AppendInt(buffer, int64(len(field)), 10)
+	buffer = append(buffer, ':')
+	buffer = append(buffer, field...)
+	return append(buffer, ',')
+}
+
+func readNetstring(buffer []byte) ([]byte, []byte, error) {
+	colon := bytes.IndexByte(buffer, ':')
+	if colon <= 0 {
+		return nil, nil, ErrMalformedSecuredData
+	}
+	length, err := strconv.Atoi(string(buffer[:colon]))
+	if err != nil || length < 0 || len(buffer) < colon+1+length+1 {
+		return nil, nil, ErrMalformedSecuredData
+	}
+	end := colon + 1 + length
+	if buffer[end] != ',' {
+		return nil, nil, ErrMalformedSecuredData
+	}
+	return buffer[colon+1 : end], buffer[end+1:], nil
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+func TestParseSecuredData(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+
+	t.Run("v1", func(t *testing.T) {
+		device, _ := NewSignatureDeviceWithFraming("device_1", "label", signer, FRAMING_V1)
+		first, _ := device.Sign("ignored")
+		data := []byte("1_data_with_underscores_")
+		signature, err := device.SignWithOptions(data, SignOptions{})
+		if err != nil {
+			t.Fatal("Error while signing, got:", err)
+		}
+
+		secured_data, err := ParseSecuredData(signature.Signed_Data)
+		if err != nil {
+			t.Fatal("Error while parsing secured data, got:", err)
+		}
+		if secured_data.Framing != FRAMING_V1 || secured_data.DeviceId != "device_1" || secured_data.Counter != 1 {
+			t.Error("Expected v1 framing of device_1 with counter 1, but got", secured_data)
+		}
+		if !bytes.Equal(secured_data.Data, data) || secured_data.DataMode != DATA_MODE_RAW {
+			t.Error("Expected raw data", string(data), "but got", secured_data.DataMode, string(secured_data.Data))
+		}
+		if !secured_data.Timestamp.Equal(signature.Timestamp) {
+			t.Error("Expected timestamp to be", signature.Timestamp, "but got", secured_data.Timestamp)
+		}
+		if !bytes.Equal(secured_data.PreviousSignatureHash, hashPreviousSignature(first.Signature)) {
+			t.Error("Expected hash of the previous signature")
+		}
+	})
+
+	t.Run("v1 digest", func(t *testing.T) {
+		device, _ := NewSignatureDeviceWithFraming("device_3", "label", signer, FRAMING_V1)
+		digest := sha256.Sum256([]byte("data"))
+		signature, _ := device.SignWithOptions([]byte(hex.EncodeToString(digest[:])), SignOptions{Digest: true})
+		secured_data, err := ParseSecuredData(signature.Signed_Data)
+		if err != nil {
+			t.Fatal("Error while parsing secured data, got:", err)
+		}
+		if secured_data.DataMode != DATA_MODE_SHA256_DIGEST {
+			t.Error("Expected digest data mode, but got", secured_data.DataMode)
+		}
+	})
+
+	t.Run("v1 first signature", func(t *testing.T) {
+		device, _ := NewSignatureDeviceWithFraming("device_2", "label", signer, FRAMING_V1)
+		signature, _ := device.Sign("data")
+		secured_data, err := ParseSecuredData(signature.Signed_Data)
+		if err != nil {
+			t.Fatal("Error while parsing secured data, got:", err)
+		}
+		expected := sha256.Sum256([]byte("device_2"))
+		if !bytes.Equal(secured_data.PreviousSignatureHash, expected[:]) {
+			t.Error("Expected hash of the device id for the first signature")
+		}
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		secured_data, err := ParseSecuredData([]byte("12_a_b_c_c2lnbmF0dXJl"))
+		if err != nil {
+			t.Fatal("Error while parsing secured data, got:", err)
+		}
+		if secured_data.Framing != FRAMING_LEGACY || secured_data.Counter != 12 {
+			t.Error("Expected legacy framing with counter 12, but got", secured_data)
+		}
+		if string(secured_data.Data) != "a_b_c" || secured_data.PreviousSignature != "c2lnbmF0dXJl" {
+			t.Error("Expected data a_b_c and previous signature c2lnbmF0dXJl, but got", secured_data)
+		}
+	})
+
+	malformed := map[string][]byte{
+		"no separators":       []byte("data"),
+		"no counter":          []byte("x_data_sig"),
+		"truncated netstring": []byte("2:v1,8:device_1,1:0,"),
+		"bad length":          []byte("2:v1,99:device_1,"),
+		"unknown data mode":   frameV1("id", 0, time.Now(), "", "unknown", []byte("data")),
+		"missing comma":       append(frameV1("id", 0, time.Now(), "", DATA_MODE_RAW, []byte("data"))[:20], 'x'),
+	}
+	for name, signedData := range malformed {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSecuredData(signedData); err != ErrMalformedSecuredData {
+				t.Error("Expected ErrMalformedSecuredData, but got", err)
+			}
+		})
+	}
+}
+
+func TestNewSignatureDeviceWithFraming(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+
+	device, err := NewSignatureDeviceWithFraming("id", "label", signer, "")
+	if err != nil || device.Framing() != FRAMING_LEGACY {
+		t.Error("Expected legacy framing by default, but got", err)
+	}
+	if _, err := NewSignatureDeviceWithFraming("id", "label", signer, "json"); err != ErrUnsupportedFraming {
+		t.Error("Expected ErrUnsupportedFraming, but got", err)
+	}
+}
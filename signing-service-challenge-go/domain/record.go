@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+// SignatureDeviceRecord is the storable representation of a signature device
+type SignatureDeviceRecord struct {
+	Id               string    `json:"id"`
+	Label            string    `json:"label"`
+	Algorithm        string    `json:"algorithm"`
+	Hash             string    `json:"hash,omitempty"`
+	Padding          string    `json:"padding,omitempty"`
+	Deterministic    bool      `json:"deterministic,omitempty"`
+	Framing          string    `json:"framing,omitempty"`
+	PublicKey        []byte    `json:"public_key"`
+	PrivateKey       []byte    `json:"private_key"`
+	SignatureCounter int       `json:"signature_counter"`
+	LastSignature    string    `json:"last_signature"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ToRecord captures the current state of the device including its PEM encoded keys
+func (d *SignatureDevice) ToRecord() (*SignatureDeviceRecord, error) {
+	marshaler, err := crypto.GetKeyMarshaler(d.signer.Algorithm())
+	if err != nil {
+		return nil, err
+	}
+	publicKey, privateKey, err := marshaler.Marshal(d.signer)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &SignatureDeviceRecord{
+		Id:               d.Id,
+		Label:            d.Label,
+		Algorithm:        d.signer.Algorithm(),
+		Hash:             d.signer.Scheme().Hash,
+		Padding:          d.signer.Scheme().Padding,
+		Deterministic:    d.signer.Scheme().Deterministic,
+		Framing:          d.framing,
+		PublicKey:        publicKey,
+		PrivateKey:       privateKey,
+		SignatureCounter: d.signature_counter,
+		LastSignature:    d.last_signature,
+		CreatedAt:        d.created_at,
+	}, nil
+}
+
+// NewSignatureDeviceFromRecord restores a signature device from a record
+func NewSignatureDeviceFromRecord(record *SignatureDeviceRecord) (*SignatureDevice, error) {
+	algorithm, err := crypto.GetAlgorithm(record.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	// records written before schemes were configurable have no scheme and get the default
+	scheme, err := algorithm.Scheme(crypto.SignatureScheme{
+		Hash:          record.Hash,
+		Padding:       record.Padding,
+		Deterministic: record.Deterministic,
+	})
+	if err != nil {
+		return nil, err
+	}
+	signer, err := algorithm.Marshaler.Unmarshal(record.PrivateKey, scheme)
+	if err != nil {
+		return nil, err
+	}
+	// records written before framing was configurable use legacy framing
+	framing, err := validFraming(record.Framing)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignatureDevice{
+		Id:                record.Id,
+		Label:             record.Label,
+		signer:            signer,
+		signature_counter: record.SignatureCounter,
+		last_signature:    record.LastSignature,
+		framing:           framing,
+		created_at:        record.CreatedAt,
+	}, nil
+}
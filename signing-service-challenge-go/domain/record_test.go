@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+func TestSignatureDeviceRecord(t *testing.T) {
+	for _, algorithm := range []string{crypto.ALGORITHM_RSA, crypto.ALGORITHM_ECC} {
+		t.Run(algorithm, func(t *testing.T) {
+			signer, err := crypto.CreateSigner(algorithm)
+			if err != nil {
+				t.Fatal("Error while creating signer, got:", err)
+			}
+			device := NewSignatureDevice("id", "label", signer)
+			device.Sign("test_data")
+
+			record, err := device.ToRecord()
+			if err != nil {
+				t.Fatal("Error while creating record, got:", err)
+			}
+			if record.Algorithm != algorithm {
+				t.Error("Expected algorithm", algorithm, "but got", record.Algorithm)
+			}
+
+			restored, err := NewSignatureDeviceFromRecord(record)
+			if err != nil {
+				t.Fatal("Error while restoring device, got:", err)
+			}
+			if restored.SignatureCounter() != 1 || restored.LastSignature() != device.LastSignature() {
+				t.Error("Expected restored device to keep its signature state")
+			}
+			if !restored.CreatedAt().Equal(device.CreatedAt()) {
+				t.Error("Expected creation time", device.CreatedAt(), "but got", restored.CreatedAt())
+			}
+
+			signature, err := restored.Sign("more_data")
+			if err != nil {
+				t.Fatal("Error while signing, got:", err)
+			}
+			decoded, _ := base64.StdEncoding.DecodeString(signature.Signature)
+			if err := device.Verify(signature.Signed_Data, decoded); err != nil {
+				t.Error("Expected restored device to sign with the original key, got:", err)
+			}
+		})
+	}
+
+	t.Run("Signature scheme", func(t *testing.T) {
+		scheme := crypto.SignatureScheme{Hash: crypto.HASH_SHA384, Padding: crypto.PADDING_PKCS1V15}
+		signer, _ := crypto.CreateSignerWithParameters(crypto.ALGORITHM_RSA, crypto.KeyParameters{}, scheme)
+		record, _ := NewSignatureDevice("id", "label", signer).ToRecord()
+
+		restored, err := NewSignatureDeviceFromRecord(record)
+		if err != nil {
+			t.Fatal("Error while restoring device, got:", err)
+		}
+		if restored.Scheme() != scheme {
+			t.Error("Expected scheme", scheme, "but got", restored.Scheme())
+		}
+	})
+
+	t.Run("Framing", func(t *testing.T) {
+		signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+		device, _ := NewSignatureDeviceWithFraming("id", "label", signer, FRAMING_V1)
+		record, _ := device.ToRecord()
+
+		restored, err := NewSignatureDeviceFromRecord(record)
+		if err != nil {
+			t.Fatal("Error while restoring device, got:", err)
+		}
+		if restored.Framing() != FRAMING_V1 {
+			t.Error("Expected framing", FRAMING_V1, "but got", restored.Framing())
+		}
+	})
+
+	t.Run("Unknown algorithm", func(t *testing.T) {
+		_, err := NewSignatureDeviceFromRecord(&SignatureDeviceRecord{Algorithm: "DSA"})
+		if err != crypto.ErrUnknownAlgorithm {
+			t.Error("Expected ErrUnknownAlgorithm, got:", err)
+		}
+	})
+}
@@ -1,5 +1,8 @@
 module github.com/fiskaly/coding-challenges/signing-service-challenge
 
-go 1.20
+go 1.24
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/google/uuid v1.6.0
+	github.com/mattn/go-sqlite3 v1.14.22
+)
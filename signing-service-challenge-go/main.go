@@ -1,18 +1,54 @@
 package main
 
 import (
+	"encoding/base64"
 	"log"
+	"os"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/api"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
 )
 
 const (
 	ListenAddress = ":8080"
+	// DataDirectory is the default directory devices and transactions are stored in.
+	DataDirectory = "data"
+	// DataDirectoryEnv overrides the data directory.
+	DataDirectoryEnv = "SIGNING_SERVICE_DATA_DIR"
+	// EncryptionKeyEnv holds the base64 encoded AES key used to encrypt private keys on disk.
+	EncryptionKeyEnv = "SIGNING_SERVICE_ENCRYPTION_KEY"
+	// AllowUnencryptedKeysEnv must be set to "true" to store private keys without encryption.
+	AllowUnencryptedKeysEnv = "SIGNING_SERVICE_ALLOW_UNENCRYPTED_KEYS"
 	// TODO: add further configuration parameters here ...
 )
 
 func main() {
-	server := api.NewServer(ListenAddress)
+	dataDirectory := os.Getenv(DataDirectoryEnv)
+	if dataDirectory == "" {
+		dataDirectory = DataDirectory
+	}
+
+	encryptionKey, err := base64.StdEncoding.DecodeString(os.Getenv(EncryptionKeyEnv))
+	if err != nil {
+		log.Fatal("Could not decode ", EncryptionKeyEnv, ": ", err)
+	}
+	if len(encryptionKey) == 0 {
+		if os.Getenv(AllowUnencryptedKeysEnv) != "true" {
+			log.Fatal("No ", EncryptionKeyEnv, " set, set ", AllowUnencryptedKeysEnv, "=true to store private keys unencrypted")
+		}
+		log.Print("No ", EncryptionKeyEnv, " set, private keys are stored unencrypted")
+	}
+
+	transactionRepository, err := persistence.NewFileTransactionRepository(dataDirectory)
+	if err != nil {
+		log.Fatal("Could not open transaction log in ", dataDirectory, ": ", err)
+	}
+	deviceRepository, err := persistence.NewFileSignatureDeviceRepository(dataDirectory, encryptionKey, transactionRepository)
+	if err != nil {
+		log.Fatal("Could not load signature devices from ", dataDirectory, ": ", err)
+	}
+
+	server := api.NewServerWithRepositories(ListenAddress, deviceRepository, transactionRepository)
 
 	if err := server.Run(); err != nil {
 		log.Fatal("Could not start server on ", ListenAddress)
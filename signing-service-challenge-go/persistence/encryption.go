@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// ErrDecryptionFailed is returned when stored key material cannot be decrypted
+var ErrDecryptionFailed = errors.New("could not decrypt key material")
+
+// keyEncrypter encrypts private key material with AES-GCM before it is stored
+type keyEncrypter struct {
+	aead cipher.AEAD
+}
+
+// newKeyEncrypter creates a key encrypter from a 16, 24 or 32 byte AES key
+func newKeyEncrypter(encryptionKey []byte) (*keyEncrypter, error) {
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &keyEncrypter{aead: aead}, nil
+}
+
+// encrypt returns the nonce followed by the sealed plaintext
+func (e *keyEncrypter) encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return e.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decrypt opens a ciphertext created by encrypt
+func (e *keyEncrypter) decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := e.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrDecryptionFailed
+	}
+	plaintext, err := e.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,391 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// FileSignatureDeviceRepository is a signature device repository that keeps a
+// JSON snapshot of every device record in a data directory. All devices are loaded
+// into memory on startup, every change is written to disk before it becomes visible.
+// The transaction log is the source of truth for the signature counter: snapshots
+// that lag behind it are rolled forward when the devices are loaded.
+type FileSignatureDeviceRepository struct {
+	directory    string
+	encrypter    *keyEncrypter
+	devices      map[string]*domain.SignatureDevice
+	transactions TransactionRepository
+	locks        deviceLocks
+	rwmu         sync.RWMutex
+}
+
+// NewFileSignatureDeviceRepository creates a file based signature device repository
+// and restores all devices found in the data directory. If an encryption key is
+// given, private keys are stored encrypted with AES, otherwise as plain PEM.
+// Signatures created by updates are recorded in the given transaction repository.
+func NewFileSignatureDeviceRepository(dataDirectory string, encryptionKey []byte, transactionRepository TransactionRepository) (*FileSignatureDeviceRepository, error) {
+	r := &FileSignatureDeviceRepository{
+		directory:    filepath.Join(dataDirectory, "devices"),
+		devices:      make(map[string]*domain.SignatureDevice),
+		transactions: transactionRepository,
+	}
+	if len(encryptionKey) > 0 {
+		encrypter, err := newKeyEncrypter(encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		r.encrypter = encrypter
+	}
+
+	if err := os.MkdirAll(r.directory, 0700); err != nil {
+		return nil, err
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Save saves a signature device in the repository
+func (r *FileSignatureDeviceRepository) Save(device *domain.SignatureDevice) error {
+	r.rwmu.Lock()
+	defer r.rwmu.Unlock()
+
+	_, ok := r.devices[device.Id]
+	if ok {
+		return ErrDeviceExists
+	}
+
+	if err := r.write(device); err != nil {
+		return err
+	}
+	r.devices[device.Id] = device
+	return nil
+}
+
+// FindById finds a signature device by its id in the repository
+func (r *FileSignatureDeviceRepository) FindById(id string) (*domain.SignatureDevice, error) {
+	r.rwmu.RLock()
+	defer r.rwmu.RUnlock()
+
+	device, ok := r.devices[id]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+	return device, nil
+}
+
+// FindAll returns all signature devices in the repository
+func (r *FileSignatureDeviceRepository) FindAll() ([]*domain.SignatureDevice, error) {
+	r.rwmu.RLock()
+	defer r.rwmu.RUnlock()
+
+	devices := make([]*domain.SignatureDevice, 0, len(r.devices))
+	for _, device := range r.devices {
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// Update applies the update function to a copy of a signature device, records
+// the created signatures and writes the new state to disk. The copy replaces the
+// device once the signatures have been recorded, a snapshot that cannot be
+// written afterwards is only logged. Updates of the same device are serialized,
+// updates of different devices run concurrently.
+func (r *FileSignatureDeviceRepository) Update(id string, update func(device *domain.SignatureDevice) ([]*domain.Signature, error)) error {
+	// only existing devices get a lock
+	if _, err := r.FindById(id); err != nil {
+		return err
+	}
+	unlock := r.locks.lock(id)
+	defer unlock()
+
+	device, err := r.FindById(id)
+	if err != nil {
+		return err
+	}
+	updated := device.Clone()
+	signatures, err := update(updated)
+	if err != nil {
+		return err
+	}
+	if err := r.transactions.Save(signatures...); err != nil {
+		return err
+	}
+
+	// the signatures are recorded, so the update has succeeded even if the
+	// snapshot cannot be written: the snapshot is rolled forward on the next load
+	r.rwmu.Lock()
+	r.devices[id] = updated
+	r.rwmu.Unlock()
+	if err := r.write(updated); err != nil {
+		log.Printf("Error while writing snapshot of signature device %s: %v", id, err)
+	}
+	return nil
+}
+
+func (r *FileSignatureDeviceRepository) load() error {
+	entries, err := os.ReadDir(r.directory)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(r.directory, entry.Name()))
+		if err != nil {
+			return err
+		}
+		var record domain.SignatureDeviceRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return err
+		}
+
+		if r.encrypter != nil {
+			record.PrivateKey, err = r.encrypter.decrypt(record.PrivateKey)
+			if err != nil {
+				return err
+			}
+		}
+		if err := r.rollForward(&record); err != nil {
+			return err
+		}
+		device, err := domain.NewSignatureDeviceFromRecord(&record)
+		if err != nil {
+			return err
+		}
+		r.devices[device.Id] = device
+	}
+	return nil
+}
+
+// rollForward advances the signature state of a record to the last signature
+// in the transaction log, in case the snapshot was not written after signing
+func (r *FileSignatureDeviceRepository) rollForward(record *domain.SignatureDeviceRecord) error {
+	signatures, err := r.transactions.FindByDeviceId(record.Id)
+	if err != nil {
+		return err
+	}
+	for _, signature := range signatures {
+		if signature.Counter == record.SignatureCounter {
+			record.SignatureCounter++
+			record.LastSignature = signature.Signature
+		}
+	}
+	return nil
+}
+
+func (r *FileSignatureDeviceRepository) write(device *domain.SignatureDevice) error {
+	record, err := device.ToRecord()
+	if err != nil {
+		return err
+	}
+	if r.encrypter != nil {
+		record.PrivateKey, err = r.encrypter.encrypt(record.PrivateKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filepath.Join(r.directory, fileName(device.Id, ".json")), content)
+}
+
+// FileTransactionRepository is a transaction repository that appends the
+// signatures of every device to a JSON lines file in a data directory. Every
+// line is a versioned transaction record. The idempotency keys of all
+// transactions are indexed in memory.
+type FileTransactionRepository struct {
+	directory       string
+	locks           deviceLocks
+	idempotencyKeys idempotencyIndex
+	keysMu          sync.RWMutex
+}
+
+// NewFileTransactionRepository creates a file based transaction repository
+func NewFileTransactionRepository(dataDirectory string) (*FileTransactionRepository, error) {
+	directory := filepath.Join(dataDirectory, "transactions")
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+	r := &FileTransactionRepository{
+		directory:       directory,
+		idempotencyKeys: make(idempotencyIndex),
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load indexes the idempotency keys of all transactions
+func (r *FileTransactionRepository) load() error {
+	entries, err := os.ReadDir(r.directory)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
+			continue
+		}
+		records, err := readTransactionLog(filepath.Join(r.directory, entry.Name()))
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			r.idempotencyKeys.add(record.toSignature())
+		}
+	}
+	return nil
+}
+
+// Save appends signatures to the transaction logs of their devices. The
+// signatures of a device are appended with a single write, which is undone if
+// it cannot be completed.
+func (r *FileTransactionRepository) Save(signatures ...*domain.Signature) error {
+	lines := make(map[string][]byte)
+	for _, signature := range signatures {
+		line, err := json.Marshal(newTransactionRecord(signature))
+		if err != nil {
+			return err
+		}
+		lines[signature.DeviceId] = append(append(lines[signature.DeviceId], line...), '\n')
+	}
+	for deviceId, content := range lines {
+		if err := r.append(deviceId, content); err != nil {
+			return err
+		}
+	}
+
+	r.keysMu.Lock()
+	defer r.keysMu.Unlock()
+	for _, signature := range signatures {
+		r.idempotencyKeys.add(signature)
+	}
+	return nil
+}
+
+func (r *FileTransactionRepository) append(deviceId string, content []byte) error {
+	unlock := r.locks.lock(deviceId)
+	defer unlock()
+
+	path := filepath.Join(r.directory, fileName(deviceId, ".jsonl"))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Truncate(info.Size())
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Truncate(info.Size())
+		return err
+	}
+	return nil
+}
+
+// FindByDeviceId returns all signatures of a device ordered by their counter
+func (r *FileTransactionRepository) FindByDeviceId(deviceId string) ([]*domain.Signature, error) {
+	unlock := r.locks.lock(deviceId)
+	defer unlock()
+
+	records, err := readTransactionLog(filepath.Join(r.directory, fileName(deviceId, ".jsonl")))
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]*domain.Signature, 0, len(records))
+	for _, record := range records {
+		signatures = append(signatures, record.toSignature())
+	}
+
+	sortByCounter(signatures)
+	return signatures, nil
+}
+
+// FindByIdempotencyKey returns the signature a device created for an idempotency key
+func (r *FileTransactionRepository) FindByIdempotencyKey(deviceId string, idempotencyKey string) (*domain.Signature, error) {
+	r.keysMu.RLock()
+	defer r.keysMu.RUnlock()
+
+	return r.idempotencyKeys.find(deviceId, idempotencyKey)
+}
+
+// readTransactionLog reads all records of a transaction log. A missing log has no records.
+func readTransactionLog(path string) ([]*transactionRecord, error) {
+	records := make([]*transactionRecord, 0)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		record, err := decodeTransactionRecord(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// fileName derives a file name from a device id that is safe to use on any file system
+func fileName(id string, extension string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id)) + extension
+}
+
+// writeFileAtomically replaces a file by writing to a temporary file first,
+// so that a crash never leaves a partially written file behind
+func writeFileAtomically(path string, content []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+func TestFileSignatureDeviceRepository(t *testing.T) {
+	directory := t.TempDir()
+	transactions, err := NewFileTransactionRepository(directory)
+	if err != nil {
+		t.Fatal("Error while creating transaction repository, got:", err)
+	}
+	repo, err := NewFileSignatureDeviceRepository(directory, testEncryptionKey, transactions)
+	if err != nil {
+		t.Fatal("Error while creating repository, got:", err)
+	}
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_RSA)
+	repo.Save(domain.NewSignatureDevice("1", "test_device", signer))
+
+	var firstSignature *domain.Signature
+	err = repo.Update("1", func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+		var err error
+		firstSignature, err = device.Sign("test_data")
+		return []*domain.Signature{firstSignature}, err
+	})
+	if err != nil {
+		t.Fatal("Error while signing, got:", err)
+	}
+
+	t.Run("Save_DuplicateDevice", func(t *testing.T) {
+		err := repo.Save(domain.NewSignatureDevice("1", "other_device", signer))
+		if err != ErrDeviceExists {
+			t.Error("Expected to get ErrDeviceExists, but got:", err)
+		}
+	})
+
+	t.Run("Restart_RestoresDevice", func(t *testing.T) {
+		restarted, err := NewFileSignatureDeviceRepository(directory, testEncryptionKey, transactions)
+		if err != nil {
+			t.Fatal("Error while restoring repository, got:", err)
+		}
+		device, err := restarted.FindById("1")
+		if err != nil {
+			t.Fatal("Expected to find device with id 1, but got error:", err)
+		}
+		if device.SignatureCounter() != 1 || device.LastSignature() != firstSignature.Signature {
+			t.Error("Expected signature state to survive a restart")
+		}
+
+		signature, err := device.Sign("more_data")
+		if err != nil {
+			t.Fatal("Error while signing, got:", err)
+		}
+		if signature.Counter != 1 {
+			t.Error("Expected restored device to continue with counter 1, but got", signature.Counter)
+		}
+	})
+
+	t.Run("Restart_RollsForwardStaleSnapshot", func(t *testing.T) {
+		// a signature that was recorded without the snapshot being written
+		transactions.Save(&domain.Signature{DeviceId: "1", Counter: 1, Signature: "unsnapshotted"})
+
+		restarted, err := NewFileSignatureDeviceRepository(directory, testEncryptionKey, transactions)
+		if err != nil {
+			t.Fatal("Error while restoring repository, got:", err)
+		}
+		device, _ := restarted.FindById("1")
+		if device.SignatureCounter() != 2 || device.LastSignature() != "unsnapshotted" {
+			t.Error("Expected signature state to be rolled forward to counter 2, but got", device.SignatureCounter())
+		}
+	})
+
+	t.Run("Restart_WrongEncryptionKey", func(t *testing.T) {
+		_, err := NewFileSignatureDeviceRepository(directory, []byte("fedcba9876543210fedcba9876543210"), transactions)
+		if err != ErrDecryptionFailed {
+			t.Error("Expected to get ErrDecryptionFailed, but got:", err)
+		}
+	})
+}
+
+func TestFileSignatureDeviceRepository_UpdateWithoutSnapshot(t *testing.T) {
+	directory := t.TempDir()
+	transactions, _ := NewFileTransactionRepository(directory)
+	repo, _ := NewFileSignatureDeviceRepository(directory, testEncryptionKey, transactions)
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	repo.Save(domain.NewSignatureDevice("1", "test_device", signer))
+
+	// replace the snapshot directory by a file, so that no snapshot can be written
+	os.RemoveAll(filepath.Join(directory, "devices"))
+	os.WriteFile(filepath.Join(directory, "devices"), nil, 0600)
+
+	err := repo.Update("1", func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+		signature, err := device.Sign("test_data")
+		return []*domain.Signature{signature}, err
+	})
+	if err != nil {
+		t.Fatal("Expected a recorded signature to succeed without a snapshot, but got:", err)
+	}
+	device, _ := repo.FindById("1")
+	if device.SignatureCounter() != 1 {
+		t.Error("Expected signature counter 1, but got", device.SignatureCounter())
+	}
+}
+
+func TestFileTransactionRepository(t *testing.T) {
+	directory := t.TempDir()
+	repo, err := NewFileTransactionRepository(directory)
+	if err != nil {
+		t.Fatal("Error while creating repository, got:", err)
+	}
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 1, Signature: "second"})
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 0, Signature: "first", Data: []byte{0x00, 0xff}, IdempotencyKey: "key"})
+
+	restarted, _ := NewFileTransactionRepository(directory)
+	signatures, err := restarted.FindByDeviceId("1")
+	if err != nil {
+		t.Fatal("Expected to find transactions of device 1, but got error:", err)
+	}
+	if len(signatures) != 2 {
+		t.Fatal("Expected to find 2 transactions, but got", len(signatures))
+	}
+	if signatures[0].Signature != "first" || signatures[1].Signature != "second" {
+		t.Error("Expected transactions to be ordered by counter")
+	}
+	if !bytes.Equal(signatures[0].Data, []byte{0x00, 0xff}) {
+		t.Error("Expected binary data to survive a restart, but got", signatures[0].Data)
+	}
+	signature, err := restarted.FindByIdempotencyKey("1", "key")
+	if err != nil || signature.Signature != "first" {
+		t.Error("Expected idempotency keys to survive a restart, but got error:", err)
+	}
+}
+
+func TestFileTransactionRepository_UnsupportedRecordVersion(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "transactions", fileName("1", ".jsonl"))
+	os.MkdirAll(filepath.Dir(path), 0700)
+	os.WriteFile(path, []byte(`{"version":2,"device_id":"1","counter":0}`+"\n"), 0600)
+
+	if _, err := NewFileTransactionRepository(directory); err != ErrUnsupportedRecordVersion {
+		t.Error("Expected to get ErrUnsupportedRecordVersion, but got:", err)
+	}
+}
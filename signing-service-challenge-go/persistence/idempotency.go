@@ -0,0 +1,26 @@
+package persistence
+
+import "github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+
+// idempotencyIndex finds the signatures created for idempotency keys, the keys
+// are scoped by device. Callers synchronize access.
+type idempotencyIndex map[string]*domain.Signature
+
+func idempotencyIndexKey(deviceId string, idempotencyKey string) string {
+	return deviceId + "\x00" + idempotencyKey
+}
+
+// add indexes a signature if it was created for an idempotency key
+func (i idempotencyIndex) add(signature *domain.Signature) {
+	if signature.IdempotencyKey != "" {
+		i[idempotencyIndexKey(signature.DeviceId, signature.IdempotencyKey)] = signature
+	}
+}
+
+func (i idempotencyIndex) find(deviceId string, idempotencyKey string) (*domain.Signature, error) {
+	signature, ok := i[idempotencyIndexKey(deviceId, idempotencyKey)]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	return signature, nil
+}
@@ -2,6 +2,7 @@ package persistence
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
@@ -13,23 +14,44 @@ var ErrDeviceExists = errors.New("device already exists")
 // ErrDeviceNotFound is returned when a device is not found in the repository
 var ErrDeviceNotFound = errors.New("device not found")
 
+// ErrTransactionNotFound is returned when a transaction is not found in the repository
+var ErrTransactionNotFound = errors.New("transaction not found")
+
 // SignatureDeviceRepository defines the contract for a signature device repository
 type SignatureDeviceRepository interface {
 	Save(device *domain.SignatureDevice) error
 	FindById(id string) (*domain.SignatureDevice, error)
 	FindAll() ([]*domain.SignatureDevice, error)
+	// Update applies the update function to a copy of the device with the given
+	// id and persists the resulting state together with the signatures returned
+	// by the update, either both or none of them. Concurrent updates of the same
+	// device must not get lost, so that the signature counter stays free of gaps.
+	Update(id string, update func(device *domain.SignatureDevice) ([]*domain.Signature, error)) error
+}
+
+// TransactionRepository defines the contract for a repository of created signatures
+type TransactionRepository interface {
+	// Save stores signatures in the transaction logs of their devices, either all or none of them
+	Save(signatures ...*domain.Signature) error
+	FindByDeviceId(deviceId string) ([]*domain.Signature, error)
+	// FindByIdempotencyKey returns the signature a device created for an idempotency key
+	FindByIdempotencyKey(deviceId string, idempotencyKey string) (*domain.Signature, error)
 }
 
 // InMemorySignatureDeviceRepository is an in-memory implementation of a signature device repository
 type InMemorySignatureDeviceRepository struct {
-	devices map[string]*domain.SignatureDevice
-	rwmu    sync.RWMutex
+	devices      map[string]*domain.SignatureDevice
+	transactions TransactionRepository
+	locks        deviceLocks
+	rwmu         sync.RWMutex
 }
 
 // NewInMemorySignatureDeviceRepository creates a new in-memory signature device repository
-func NewInMemorySignatureDeviceRepository() *InMemorySignatureDeviceRepository {
+// that records the signatures created by updates in the given transaction repository
+func NewInMemorySignatureDeviceRepository(transactionRepository TransactionRepository) *InMemorySignatureDeviceRepository {
 	return &InMemorySignatureDeviceRepository{
-		devices: make(map[string]*domain.SignatureDevice),
+		devices:      make(map[string]*domain.SignatureDevice),
+		transactions: transactionRepository,
 	}
 }
 
@@ -59,6 +81,36 @@ func (r *InMemorySignatureDeviceRepository) FindById(id string) (*domain.Signatu
 	return device, nil
 }
 
+// Update applies the update function to a copy of a signature device. The copy
+// replaces the device only after the created signatures have been recorded, so
+// a failed update neither changes the device nor leaves a gap in its counter.
+func (r *InMemorySignatureDeviceRepository) Update(id string, update func(device *domain.SignatureDevice) ([]*domain.Signature, error)) error {
+	// only existing devices get a lock
+	if _, err := r.FindById(id); err != nil {
+		return err
+	}
+	unlock := r.locks.lock(id)
+	defer unlock()
+
+	device, err := r.FindById(id)
+	if err != nil {
+		return err
+	}
+	updated := device.Clone()
+	signatures, err := update(updated)
+	if err != nil {
+		return err
+	}
+	if err := r.transactions.Save(signatures...); err != nil {
+		return err
+	}
+
+	r.rwmu.Lock()
+	defer r.rwmu.Unlock()
+	r.devices[id] = updated
+	return nil
+}
+
 // FindAll returns all signature devices in the repository
 func (r *InMemorySignatureDeviceRepository) FindAll() ([]*domain.SignatureDevice, error) {
 	r.rwmu.RLock()
@@ -70,3 +122,55 @@ func (r *InMemorySignatureDeviceRepository) FindAll() ([]*domain.SignatureDevice
 	}
 	return devices, nil
 }
+
+// InMemoryTransactionRepository is an in-memory implementation of a transaction repository
+type InMemoryTransactionRepository struct {
+	transactions    map[string][]*domain.Signature
+	idempotencyKeys idempotencyIndex
+	rwmu            sync.RWMutex
+}
+
+// NewInMemoryTransactionRepository creates a new in-memory transaction repository
+func NewInMemoryTransactionRepository() *InMemoryTransactionRepository {
+	return &InMemoryTransactionRepository{
+		transactions:    make(map[string][]*domain.Signature),
+		idempotencyKeys: make(idempotencyIndex),
+	}
+}
+
+// Save stores signatures in the transaction logs of their devices
+func (r *InMemoryTransactionRepository) Save(signatures ...*domain.Signature) error {
+	r.rwmu.Lock()
+	defer r.rwmu.Unlock()
+
+	for _, signature := range signatures {
+		r.transactions[signature.DeviceId] = append(r.transactions[signature.DeviceId], signature)
+		r.idempotencyKeys.add(signature)
+	}
+	return nil
+}
+
+// FindByDeviceId returns all signatures of a device ordered by their counter
+func (r *InMemoryTransactionRepository) FindByDeviceId(deviceId string) ([]*domain.Signature, error) {
+	r.rwmu.RLock()
+	defer r.rwmu.RUnlock()
+
+	signatures := make([]*domain.Signature, len(r.transactions[deviceId]))
+	copy(signatures, r.transactions[deviceId])
+	sortByCounter(signatures)
+	return signatures, nil
+}
+
+// FindByIdempotencyKey returns the signature a device created for an idempotency key
+func (r *InMemoryTransactionRepository) FindByIdempotencyKey(deviceId string, idempotencyKey string) (*domain.Signature, error) {
+	r.rwmu.RLock()
+	defer r.rwmu.RUnlock()
+
+	return r.idempotencyKeys.find(deviceId, idempotencyKey)
+}
+
+func sortByCounter(signatures []*domain.Signature) {
+	sort.Slice(signatures, func(i, j int) bool {
+		return signatures[i].Counter < signatures[j].Counter
+	})
+}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
@@ -8,7 +9,7 @@ import (
 )
 
 func TestInMemorySignatureDeviceRepository(t *testing.T) {
-	repo := NewInMemorySignatureDeviceRepository()
+	repo := NewInMemorySignatureDeviceRepository(NewInMemoryTransactionRepository())
 	rsaGenerator := crypto.RSAGenerator{}
 	rsaKeyPair, err := rsaGenerator.Generate()
 	if err != nil {
@@ -45,3 +46,88 @@ func TestInMemorySignatureDeviceRepository(t *testing.T) {
 		}
 	})
 }
+
+// failingTransactionRepository fails to save any signature
+type failingTransactionRepository struct {
+	InMemoryTransactionRepository
+}
+
+func (r *failingTransactionRepository) Save(signatures ...*domain.Signature) error {
+	return errors.New("disk full")
+}
+
+func TestInMemorySignatureDeviceRepository_Update(t *testing.T) {
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	sign := func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+		signature, err := device.Sign("test_data")
+		return []*domain.Signature{signature}, err
+	}
+
+	t.Run("Update_RecordsSignatures", func(t *testing.T) {
+		transactions := NewInMemoryTransactionRepository()
+		repo := NewInMemorySignatureDeviceRepository(transactions)
+		repo.Save(domain.NewSignatureDevice("1", "test_device", signer))
+
+		if err := repo.Update("1", sign); err != nil {
+			t.Fatal("Error while updating device, got:", err)
+		}
+		device, _ := repo.FindById("1")
+		signatures, _ := transactions.FindByDeviceId("1")
+		if device.SignatureCounter() != 1 || len(signatures) != 1 {
+			t.Error("Expected counter 1 and 1 transaction, but got", device.SignatureCounter(), len(signatures))
+		}
+	})
+
+	t.Run("Update_FailedSaveKeepsDevice", func(t *testing.T) {
+		repo := NewInMemorySignatureDeviceRepository(&failingTransactionRepository{})
+		repo.Save(domain.NewSignatureDevice("1", "test_device", signer))
+
+		if err := repo.Update("1", sign); err == nil {
+			t.Fatal("Expected update to fail")
+		}
+		device, _ := repo.FindById("1")
+		if device.SignatureCounter() != 0 {
+			t.Error("Expected counter to stay at 0, but got", device.SignatureCounter())
+		}
+	})
+}
+
+func TestInMemoryTransactionRepository(t *testing.T) {
+	repo := NewInMemoryTransactionRepository()
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 1})
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 0})
+	repo.Save(&domain.Signature{DeviceId: "2", Counter: 0, IdempotencyKey: "key"})
+
+	t.Run("FindByDeviceId_OrderedByCounter", func(t *testing.T) {
+		signatures, err := repo.FindByDeviceId("1")
+		if err != nil {
+			t.Error("Expected to find transactions of device 1, but got error:", err)
+		}
+		if len(signatures) != 2 {
+			t.Fatal("Expected to find 2 transactions, but got", len(signatures))
+		}
+		if signatures[0].Counter != 0 || signatures[1].Counter != 1 {
+			t.Error("Expected transactions to be ordered by counter")
+		}
+	})
+
+	t.Run("FindByDeviceId_NoTransactions", func(t *testing.T) {
+		signatures, err := repo.FindByDeviceId("3")
+		if err != nil {
+			t.Error("Expected no error, but got:", err)
+		}
+		if len(signatures) != 0 {
+			t.Error("Expected to find no transactions, but got", len(signatures))
+		}
+	})
+
+	t.Run("FindByIdempotencyKey", func(t *testing.T) {
+		signature, err := repo.FindByIdempotencyKey("2", "key")
+		if err != nil || signature.DeviceId != "2" {
+			t.Error("Expected to find the transaction of device 2, but got error:", err)
+		}
+		if _, err := repo.FindByIdempotencyKey("1", "key"); err != ErrTransactionNotFound {
+			t.Error("Expected keys to be scoped by device, but got:", err)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package persistence
+
+import "sync"
+
+// deviceLocks serializes the updates of each device, so that updates of
+// different devices do not wait for each other
+type deviceLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks the mutex of a device and returns the function to unlock it
+func (l *deviceLocks) lock(id string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[id] = lock
+	}
+	l.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,368 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// ErrConcurrentUpdate is returned when a device could not be updated because
+// other updates of the same device kept winning the race
+var ErrConcurrentUpdate = errors.New("device was updated concurrently")
+
+// maxUpdateAttempts limits how often an update is retried after losing a race
+const maxUpdateAttempts = 10
+
+const createSignatureDevicesTable = `
+CREATE TABLE IF NOT EXISTS signature_devices (
+	id                TEXT PRIMARY KEY,
+	label             TEXT NOT NULL,
+	algorithm         TEXT NOT NULL,
+	private_key       BLOB NOT NULL,
+	signature_counter INTEGER NOT NULL,
+	last_signature    TEXT NOT NULL,
+	created_at        TEXT NOT NULL
+)`
+
+const createTransactionsTable = `
+CREATE TABLE IF NOT EXISTS transactions (
+	device_id   TEXT NOT NULL,
+	counter     INTEGER NOT NULL,
+	data        BLOB NOT NULL,
+	signature   TEXT NOT NULL,
+	signed_data BLOB NOT NULL,
+	timestamp   TEXT NOT NULL,
+	PRIMARY KEY (device_id, counter)
+)`
+
+const createIdempotencyKeyIndex = `
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key
+ON transactions (device_id, idempotency_key) WHERE idempotency_key != ''`
+
+const selectTransaction = `
+SELECT device_id, counter, data, data_mode, signature, signed_data, jws, timestamp, idempotency_key
+FROM transactions`
+
+const selectSignatureDevice = `
+SELECT id, label, algorithm, hash, padding, deterministic, framing, private_key, signature_counter, last_signature, created_at
+FROM signature_devices`
+
+// SQLSignatureDeviceRepository is a database/sql implementation of a signature device repository.
+// Private keys are stored encrypted. The signature counter is updated with a
+// compare-and-swap, so that several service instances can sign with the same
+// device without skipping or reusing a counter value. The created signatures
+// are inserted into the transactions table in the same database transaction.
+type SQLSignatureDeviceRepository struct {
+	db        *sql.DB
+	encrypter *keyEncrypter
+}
+
+// NewSQLSignatureDeviceRepository creates a new SQL signature device repository.
+// The encryption key is used to encrypt private keys with AES and must be 16, 24 or 32 bytes long.
+func NewSQLSignatureDeviceRepository(db *sql.DB, encryptionKey []byte) (*SQLSignatureDeviceRepository, error) {
+	encrypter, err := newKeyEncrypter(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLSignatureDeviceRepository{
+		db:        db,
+		encrypter: encrypter,
+	}, nil
+}
+
+// CreateSchema creates or migrates the tables used by the device and transaction
+// repositories to the latest schema version
+func (r *SQLSignatureDeviceRepository) CreateSchema() error {
+	return migrate(r.db)
+}
+
+// Save saves a signature device in the repository
+func (r *SQLSignatureDeviceRepository) Save(device *domain.SignatureDevice) error {
+	record, err := device.ToRecord()
+	if err != nil {
+		return err
+	}
+	encryptedPrivateKey, err := r.encrypter.encrypt(record.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	// a conflict on the id is detected from the insert itself, so that concurrent
+	// saves of the same device cannot race between a lookup and the insert
+	result, err := r.db.Exec(
+		`INSERT INTO signature_devices
+		(id, label, algorithm, hash, padding, deterministic, framing, private_key, signature_counter, last_signature, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		record.Id,
+		record.Label,
+		record.Algorithm,
+		record.Hash,
+		record.Padding,
+		record.Deterministic,
+		record.Framing,
+		encryptedPrivateKey,
+		record.SignatureCounter,
+		record.LastSignature,
+		record.CreatedAt.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ErrDeviceExists
+	}
+	return nil
+}
+
+// FindById finds a signature device by its id in the repository
+func (r *SQLSignatureDeviceRepository) FindById(id string) (*domain.SignatureDevice, error) {
+	row := r.db.QueryRow(selectSignatureDevice+" WHERE id = ?", id)
+	device, err := r.scanDevice(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeviceNotFound
+	}
+	return device, err
+}
+
+// FindAll returns all signature devices in the repository
+func (r *SQLSignatureDeviceRepository) FindAll() ([]*domain.SignatureDevice, error) {
+	rows, err := r.db.Query(selectSignatureDevice + " ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := make([]*domain.SignatureDevice, 0)
+	for rows.Next() {
+		device, err := r.scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+// Update loads the current state of a signature device, applies the update
+// function and writes the new state back only if no other update happened in
+// the meantime. Otherwise the update is retried on the fresh state. The new
+// state and the created signatures are committed in one database transaction.
+func (r *SQLSignatureDeviceRepository) Update(id string, update func(device *domain.SignatureDevice) ([]*domain.Signature, error)) error {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		device, err := r.FindById(id)
+		if err != nil {
+			return err
+		}
+		previousCounter := device.SignatureCounter()
+
+		signatures, err := update(device)
+		if err != nil {
+			return err
+		}
+
+		updated, err := r.compareAndSwap(device, previousCounter, signatures)
+		if err != nil {
+			return err
+		}
+		if updated {
+			return nil
+		}
+	}
+	return ErrConcurrentUpdate
+}
+
+// compareAndSwap stores the new state of a device and its signatures if the
+// stored counter still is the previous counter, and reports whether it did
+func (r *SQLSignatureDeviceRepository) compareAndSwap(device *domain.SignatureDevice, previousCounter int, signatures []*domain.Signature) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`UPDATE signature_devices
+		SET label = ?, signature_counter = ?, last_signature = ?
+		WHERE id = ? AND signature_counter = ?`,
+		device.Label,
+		device.SignatureCounter(),
+		device.LastSignature(),
+		device.Id,
+		previousCounter,
+	)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if updated != 1 {
+		return false, nil
+	}
+
+	if err := insertTransactions(tx, signatures); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *SQLSignatureDeviceRepository) scanDevice(row scanner) (*domain.SignatureDevice, error) {
+	var (
+		record              domain.SignatureDeviceRecord
+		encryptedPrivateKey []byte
+		createdAt           string
+	)
+	err := row.Scan(
+		&record.Id,
+		&record.Label,
+		&record.Algorithm,
+		&record.Hash,
+		&record.Padding,
+		&record.Deterministic,
+		&record.Framing,
+		&encryptedPrivateKey,
+		&record.SignatureCounter,
+		&record.LastSignature,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	record.PrivateKey, err = r.encrypter.decrypt(encryptedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	record.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewSignatureDeviceFromRecord(&record)
+}
+
+// SQLTransactionRepository is a database/sql implementation of a transaction repository.
+// It shares the transactions table with the SQLSignatureDeviceRepository, which
+// creates the schema and records signatures together with the counter update.
+type SQLTransactionRepository struct {
+	db *sql.DB
+}
+
+// NewSQLTransactionRepository creates a new SQL transaction repository
+func NewSQLTransactionRepository(db *sql.DB) *SQLTransactionRepository {
+	return &SQLTransactionRepository{
+		db: db,
+	}
+}
+
+// Save stores signatures in the transaction logs of their devices
+func (r *SQLTransactionRepository) Save(signatures ...*domain.Signature) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTransactions(tx, signatures); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindByDeviceId returns all signatures of a device ordered by their counter
+func (r *SQLTransactionRepository) FindByDeviceId(deviceId string) ([]*domain.Signature, error) {
+	rows, err := r.db.Query(selectTransaction+" WHERE device_id = ? ORDER BY counter", deviceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signatures := make([]*domain.Signature, 0)
+	for rows.Next() {
+		signature, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures, rows.Err()
+}
+
+// FindByIdempotencyKey returns the signature a device created for an idempotency key
+func (r *SQLTransactionRepository) FindByIdempotencyKey(deviceId string, idempotencyKey string) (*domain.Signature, error) {
+	row := r.db.QueryRow(selectTransaction+" WHERE device_id = ? AND idempotency_key = ?", deviceId, idempotencyKey)
+	signature, err := scanTransaction(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	return signature, err
+}
+
+func scanTransaction(row scanner) (*domain.Signature, error) {
+	var (
+		signature domain.Signature
+		timestamp string
+	)
+	err := row.Scan(
+		&signature.DeviceId,
+		&signature.Counter,
+		&signature.Data,
+		&signature.DataMode,
+		&signature.Signature,
+		&signature.Signed_Data,
+		&signature.JWS,
+		&timestamp,
+		&signature.IdempotencyKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	signature.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &signature, nil
+}
+
+func insertTransactions(tx *sql.Tx, signatures []*domain.Signature) error {
+	for _, signature := range signatures {
+		_, err := tx.Exec(
+			`INSERT INTO transactions
+			(device_id, counter, data, data_mode, signature, signed_data, jws, timestamp, idempotency_key)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			signature.DeviceId,
+			signature.Counter,
+			blob(signature.Data),
+			signature.DataMode,
+			signature.Signature,
+			blob(signature.Signed_Data),
+			signature.JWS,
+			signature.Timestamp.Format(time.RFC3339Nano),
+			signature.IdempotencyKey,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blob stores empty byte slices as empty blobs instead of NULL
+func blob(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
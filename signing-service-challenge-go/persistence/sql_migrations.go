@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const createSchemaVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY
+)`
+
+// migration is a single step from one schema version to the next
+type migration func(tx *sql.Tx) error
+
+// migrations are applied in order, the schema version of a database is the
+// number of migrations applied to it. Existing migrations must never change,
+// schema changes are added as new migrations at the end.
+var migrations = []migration{
+	execute(createSignatureDevicesTable),
+	execute(createTransactionsTable),
+	addColumn("signature_devices", "hash", "TEXT NOT NULL DEFAULT ''"),
+	addColumn("signature_devices", "padding", "TEXT NOT NULL DEFAULT ''"),
+	addColumn("signature_devices", "deterministic", "BOOLEAN NOT NULL DEFAULT FALSE"),
+	addColumn("transactions", "jws", "TEXT NOT NULL DEFAULT ''"),
+	addColumn("transactions", "data_mode", "TEXT NOT NULL DEFAULT 'raw'"),
+	addColumn("signature_devices", "framing", "TEXT NOT NULL DEFAULT ''"),
+	addColumn("transactions", "idempotency_key", "TEXT NOT NULL DEFAULT ''"),
+	execute(createIdempotencyKeyIndex),
+}
+
+// migrate applies all migrations a database has not seen yet in one transaction
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(createSchemaVersionTable); err != nil {
+		return err
+	}
+	var version int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		if err := migrations[version](tx); err != nil {
+			return fmt.Errorf("migration to schema version %d failed: %w", version+1, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", version+1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// execute creates a migration that runs a single statement
+func execute(statement string) migration {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(statement)
+		return err
+	}
+}
+
+// addColumn creates a migration that adds a column to a table
+func addColumn(table string, column string, definition string) migration {
+	return execute("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+}
@@ -0,0 +1,328 @@
+package persistence
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func openSQLiteRepository(t *testing.T, path string) *SQLSignatureDeviceRepository {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal("Error while opening database, got:", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewSQLSignatureDeviceRepository(db, testEncryptionKey)
+	if err != nil {
+		t.Fatal("Error while creating repository, got:", err)
+	}
+	if err := repo.CreateSchema(); err != nil {
+		t.Fatal("Error while creating schema, got:", err)
+	}
+	return repo
+}
+
+func TestSQLSignatureDeviceRepository(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.db")
+	repo := openSQLiteRepository(t, path)
+	signer, err := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	if err != nil {
+		t.Fatal("Error while creating signer, got:", err)
+	}
+	device := domain.NewSignatureDevice("1", "test_device", signer)
+	if err := repo.Save(device); err != nil {
+		t.Fatal("Error while saving device, got:", err)
+	}
+
+	t.Run("Save_DuplicateDevice", func(t *testing.T) {
+		otherDevice := domain.NewSignatureDevice("1", "other_device", signer)
+		err := repo.Save(otherDevice)
+		if err != ErrDeviceExists {
+			t.Error("Expected to get ErrDeviceExists, but got:", err)
+		}
+	})
+
+	t.Run("Save_EncryptsPrivateKey", func(t *testing.T) {
+		var privateKey []byte
+		err := repo.db.QueryRow("SELECT private_key FROM signature_devices WHERE id = ?", "1").Scan(&privateKey)
+		if err != nil {
+			t.Fatal("Error while reading private key, got:", err)
+		}
+		if bytes.Contains(privateKey, []byte("PRIVATE")) {
+			t.Error("Expected private key to be stored encrypted")
+		}
+	})
+
+	t.Run("FindById_DeviceExists", func(t *testing.T) {
+		foundDevice, err := repo.FindById("1")
+		if err != nil {
+			t.Fatal("Expected to find device with id 1, but got error:", err)
+		}
+		if foundDevice.Label != device.Label || foundDevice.Algorithm() != crypto.ALGORITHM_ECC {
+			t.Error("Expected to find device", device.Label, "but got", foundDevice.Label)
+		}
+		if !foundDevice.CreatedAt().Equal(device.CreatedAt()) {
+			t.Error("Expected creation time", device.CreatedAt(), "but got", foundDevice.CreatedAt())
+		}
+	})
+
+	t.Run("FindById_DeviceDoesNotExist", func(t *testing.T) {
+		_, err := repo.FindById("2")
+		if err != ErrDeviceNotFound {
+			t.Error("Expected to get ErrDeviceNotFound, but got:", err)
+		}
+	})
+
+	t.Run("Update_PersistsSignatureState", func(t *testing.T) {
+		var signature *domain.Signature
+		err := repo.Update("1", func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+			var err error
+			signature, err = device.Sign("test_data")
+			return []*domain.Signature{signature}, err
+		})
+		if err != nil {
+			t.Fatal("Error while updating device, got:", err)
+		}
+
+		foundDevice, _ := repo.FindById("1")
+		if foundDevice.SignatureCounter() != 1 {
+			t.Error("Expected signature counter 1, but got", foundDevice.SignatureCounter())
+		}
+		if foundDevice.LastSignature() != signature.Signature {
+			t.Error("Expected last signature to be persisted")
+		}
+	})
+
+	t.Run("Update_RecordsTransactions", func(t *testing.T) {
+		transactions := NewSQLTransactionRepository(repo.db)
+		signatures, err := transactions.FindByDeviceId("1")
+		if err != nil {
+			t.Fatal("Error while finding transactions, got:", err)
+		}
+		foundDevice, _ := repo.FindById("1")
+		if len(signatures) != 1 || signatures[0].Signature != foundDevice.LastSignature() {
+			t.Fatal("Expected the signature to be recorded with the counter, but got", len(signatures), "transactions")
+		}
+		if string(signatures[0].Data) != "test_data" || signatures[0].Timestamp.IsZero() {
+			t.Error("Expected the transaction to keep data and timestamp, but got", signatures[0])
+		}
+	})
+
+	t.Run("Update_FailedInsertKeepsCounter", func(t *testing.T) {
+		other := openSQLiteRepository(t, filepath.Join(t.TempDir(), "devices.db"))
+		other.Save(domain.NewSignatureDevice("1", "test_device", signer))
+		if _, err := other.db.Exec("DROP TABLE transactions"); err != nil {
+			t.Fatal("Error while dropping transactions, got:", err)
+		}
+
+		err := other.Update("1", func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+			signature, err := device.Sign("test_data")
+			return []*domain.Signature{signature}, err
+		})
+		if err == nil {
+			t.Fatal("Expected update to fail")
+		}
+		foundDevice, _ := other.FindById("1")
+		if foundDevice.SignatureCounter() != 0 {
+			t.Error("Expected signature counter 0, but got", foundDevice.SignatureCounter())
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		devices, err := repo.FindAll()
+		if err != nil {
+			t.Fatal("Error while finding devices, got:", err)
+		}
+		if len(devices) != 1 {
+			t.Error("Expected to find 1 device, but got", len(devices))
+		}
+	})
+}
+
+func TestSQLSignatureDeviceRepository_ConcurrentInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.db")
+	instances := []*SQLSignatureDeviceRepository{
+		openSQLiteRepository(t, path),
+		openSQLiteRepository(t, path),
+	}
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+	instances[0].Save(domain.NewSignatureDevice("1", "test_device", signer))
+
+	const signaturesPerInstance = 10
+	var mu sync.Mutex
+	counters := make(map[int]bool)
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		for i := 0; i < signaturesPerInstance; i++ {
+			wg.Add(1)
+			go func(repo *SQLSignatureDeviceRepository) {
+				defer wg.Done()
+				var signature *domain.Signature
+				err := repo.Update("1", func(device *domain.SignatureDevice) ([]*domain.Signature, error) {
+					var err error
+					signature, err = device.Sign("test_data")
+					return []*domain.Signature{signature}, err
+				})
+				if err != nil {
+					t.Error("Error while signing, got:", err)
+					return
+				}
+				mu.Lock()
+				if counters[signature.Counter] {
+					t.Error("Counter", signature.Counter, "was used twice")
+				}
+				counters[signature.Counter] = true
+				mu.Unlock()
+			}(instance)
+		}
+	}
+	wg.Wait()
+
+	device, _ := instances[0].FindById("1")
+	expected := len(instances) * signaturesPerInstance
+	if device.SignatureCounter() != expected {
+		t.Error("Expected signature counter", expected, "but got", device.SignatureCounter())
+	}
+	for counter := 0; counter < expected; counter++ {
+		if !counters[counter] {
+			t.Error("Expected a signature with counter", counter)
+		}
+	}
+
+	signatures, _ := NewSQLTransactionRepository(instances[1].db).FindByDeviceId("1")
+	if report := device.Audit(signatures); !report.Valid || report.SignatureCount != expected {
+		t.Error("Expected an unbroken chain of", expected, "transactions, but got", report.SignatureCount, report.Reason)
+	}
+}
+
+func TestSQLSignatureDeviceRepository_ConcurrentSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.db")
+	instances := []*SQLSignatureDeviceRepository{
+		openSQLiteRepository(t, path),
+		openSQLiteRepository(t, path),
+	}
+	signer, _ := crypto.CreateSigner(crypto.ALGORITHM_ECC)
+
+	const savesPerInstance = 5
+	var mu sync.Mutex
+	saved := 0
+	var wg sync.WaitGroup
+	for _, instance := range instances {
+		for i := 0; i < savesPerInstance; i++ {
+			wg.Add(1)
+			go func(repo *SQLSignatureDeviceRepository) {
+				defer wg.Done()
+				err := repo.Save(domain.NewSignatureDevice("1", "test_device", signer))
+				if err != nil && err != ErrDeviceExists {
+					t.Error("Expected to get ErrDeviceExists, but got:", err)
+					return
+				}
+				mu.Lock()
+				if err == nil {
+					saved++
+				}
+				mu.Unlock()
+			}(instance)
+		}
+	}
+	wg.Wait()
+
+	if saved != 1 {
+		t.Error("Expected the device to be saved once, but got", saved)
+	}
+}
+
+func TestSQLTransactionRepository(t *testing.T) {
+	repo := NewSQLTransactionRepository(openSQLiteRepository(t, filepath.Join(t.TempDir(), "devices.db")).db)
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 1, Signature: "second"})
+	repo.Save(&domain.Signature{DeviceId: "1", Counter: 0, Signature: "first", Data: []byte{0x00, 0xff}, IdempotencyKey: "key"})
+
+	signatures, err := repo.FindByDeviceId("1")
+	if err != nil {
+		t.Fatal("Expected to find transactions of device 1, but got error:", err)
+	}
+	if len(signatures) != 2 {
+		t.Fatal("Expected to find 2 transactions, but got", len(signatures))
+	}
+	if signatures[0].Signature != "first" || signatures[1].Signature != "second" {
+		t.Error("Expected transactions to be ordered by counter")
+	}
+	if !bytes.Equal(signatures[0].Data, []byte{0x00, 0xff}) {
+		t.Error("Expected binary data to be kept, but got", signatures[0].Data)
+	}
+
+	t.Run("FindByIdempotencyKey", func(t *testing.T) {
+		signature, err := repo.FindByIdempotencyKey("1", "key")
+		if err != nil || signature.Signature != "first" {
+			t.Error("Expected to find the transaction with key, but got error:", err)
+		}
+		if _, err := repo.FindByIdempotencyKey("2", "key"); err != ErrTransactionNotFound {
+			t.Error("Expected to get ErrTransactionNotFound, but got:", err)
+		}
+	})
+
+	t.Run("Save_DuplicateIdempotencyKey", func(t *testing.T) {
+		if err := repo.Save(&domain.Signature{DeviceId: "1", Counter: 2, IdempotencyKey: "key"}); err == nil {
+			t.Error("Expected a second transaction with the same key to be rejected")
+		}
+	})
+
+	t.Run("Save_DuplicateCounter", func(t *testing.T) {
+		if err := repo.Save(&domain.Signature{DeviceId: "1", Counter: 1}); err == nil {
+			t.Error("Expected a second transaction with counter 1 to be rejected")
+		}
+	})
+}
+
+func TestSQLSchemaMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.db")
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal("Error while opening database, got:", err)
+	}
+	defer db.Close()
+	// the schema before hash, padding, deterministic and framing were added
+	tx, _ := db.Begin()
+	tx.Exec(createSchemaVersionTable)
+	for version, migration := range migrations[:2] {
+		if err := migration(tx); err != nil {
+			t.Fatal("Error while creating old schema, got:", err)
+		}
+		tx.Exec("INSERT INTO schema_version (version) VALUES (?)", version+1)
+	}
+	tx.Commit()
+
+	repo := openSQLiteRepository(t, path)
+	signer, _ := crypto.CreateSignerWithParameters(crypto.ALGORITHM_ECC, crypto.KeyParameters{}, crypto.SignatureScheme{Hash: crypto.HASH_SHA384, Deterministic: true})
+	device, _ := domain.NewSignatureDeviceWithFraming("1", "test_device", signer, domain.FRAMING_V1)
+	if err := repo.Save(device); err != nil {
+		t.Fatal("Error while saving device in migrated schema, got:", err)
+	}
+	foundDevice, err := repo.FindById("1")
+	if err != nil {
+		t.Fatal("Expected to find device with id 1, but got error:", err)
+	}
+	if foundDevice.Scheme() != signer.Scheme() || foundDevice.Framing() != domain.FRAMING_V1 {
+		t.Error("Expected the migrated columns to be stored, but got", foundDevice.Scheme(), foundDevice.Framing())
+	}
+
+	t.Run("Idempotent", func(t *testing.T) {
+		if err := repo.CreateSchema(); err != nil {
+			t.Fatal("Error while migrating again, got:", err)
+		}
+		var version int
+		db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+		if version != len(migrations) {
+			t.Error("Expected schema version", len(migrations), "but got", version)
+		}
+	})
+}
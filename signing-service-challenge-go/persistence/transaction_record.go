@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// ErrUnsupportedRecordVersion is returned for transaction log lines written by a newer version
+var ErrUnsupportedRecordVersion = errors.New("unsupported transaction record version")
+
+// transactionRecordVersion is the current version of the transaction log format
+const transactionRecordVersion = 1
+
+// transactionRecord is a line of the file transaction log. Data and signed data
+// are binary and stored base64 encoded.
+type transactionRecord struct {
+	Version        int       `json:"version"`
+	DeviceId       string    `json:"device_id"`
+	Counter        int       `json:"counter"`
+	Data           []byte    `json:"data"`
+	DataMode       string    `json:"data_mode"`
+	Signature      string    `json:"signature"`
+	SignedData     []byte    `json:"signed_data"`
+	JWS            string    `json:"jws,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+}
+
+func newTransactionRecord(signature *domain.Signature) transactionRecord {
+	return transactionRecord{
+		Version:        transactionRecordVersion,
+		DeviceId:       signature.DeviceId,
+		Counter:        signature.Counter,
+		Data:           signature.Data,
+		DataMode:       signature.DataMode,
+		Signature:      signature.Signature,
+		SignedData:     signature.Signed_Data,
+		JWS:            signature.JWS,
+		Timestamp:      signature.Timestamp,
+		IdempotencyKey: signature.IdempotencyKey,
+	}
+}
+
+func (r transactionRecord) toSignature() *domain.Signature {
+	return &domain.Signature{
+		DeviceId:       r.DeviceId,
+		Counter:        r.Counter,
+		Data:           r.Data,
+		DataMode:       r.DataMode,
+		Signature:      r.Signature,
+		Signed_Data:    r.SignedData,
+		JWS:            r.JWS,
+		Timestamp:      r.Timestamp,
+		IdempotencyKey: r.IdempotencyKey,
+	}
+}
+
+// decodeTransactionRecord decodes a line of the transaction log
+func decodeTransactionRecord(line []byte) (*transactionRecord, error) {
+	var record transactionRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, err
+	}
+	if record.Version != transactionRecordVersion {
+		return nil, ErrUnsupportedRecordVersion
+	}
+	return &record, nil
+}